@@ -3,6 +3,8 @@ package ast
 import (
 	"bytes"
 	"learn-interpreter/token"
+	"math/big"
+	"strconv"
 	"strings"
 )
 
@@ -41,19 +43,30 @@ func (p *Program) String() string {
 	return out.String()
 }
 
+// LetStatement binds Value to Name or, when destructuring, to the
+// identifiers in Pattern. Exactly one of Name and Pattern is set.
 type LetStatement struct {
-	Token token.Token
-	Name  *Identifier
-	Value Expression
+	Token   token.Token
+	Name    *Identifier
+	Pattern Expression
+	Value   Expression
 }
 
 func (ls *LetStatement) statementNode()       {}
 func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal }
 
+// IsConst reports whether the statement is a const declaration, which is
+// parsed into a LetStatement too.
+func (ls *LetStatement) IsConst() bool { return ls.Token.Type == token.CONST }
+
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.String())
+	if ls.Pattern != nil {
+		out.WriteString(ls.Pattern.String())
+	} else {
+		out.WriteString(ls.Name.String())
+	}
 	out.WriteString(" = ")
 	if ls.Value != nil {
 		out.WriteString(ls.Value.String())
@@ -107,6 +120,8 @@ func (es *ExpressionStatement) String() string {
 type IntegerLiteral struct {
 	Token token.Token
 	Value int64
+	// Big holds the value of literals too large for Value.
+	Big *big.Int
 }
 
 func (il *IntegerLiteral) expressionNode()      {}
@@ -151,6 +166,35 @@ func (ie *InfixExpression) String() string {
 	return out.String()
 }
 
+// PipeExpression is Left |> Right. Right is a call, which gets Left as its
+// first argument, or any other expression, which is called with Left.
+type PipeExpression struct {
+	Token token.Token
+	Left  Expression
+	Right Expression
+}
+
+func (pe *PipeExpression) expressionNode()      {}
+func (pe *PipeExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PipeExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(pe.Left.String())
+	out.WriteString(" |> ")
+	out.WriteString(pe.Right.String())
+	out.WriteString(")")
+	return out.String()
+}
+
+// Call returns the call expression that the pipe stands for.
+func (pe *PipeExpression) Call() *CallExpression {
+	if call, ok := pe.Right.(*CallExpression); ok {
+		args := append([]Expression{pe.Left}, call.Arguments...)
+		return &CallExpression{Token: call.Token, Function: call.Function, Arguments: args}
+	}
+	return &CallExpression{Token: pe.Token, Function: pe.Right, Arguments: []Expression{pe.Left}}
+}
+
 type BooleanLiteral struct {
 	Token token.Token
 	Value bool
@@ -160,25 +204,81 @@ func (b *BooleanLiteral) expressionNode()      {}
 func (b *BooleanLiteral) TokenLiteral() string { return b.Token.Literal }
 func (b *BooleanLiteral) String() string       { return b.Token.Literal }
 
+type NullLiteral struct {
+	Token token.Token
+}
+
+func (n *NullLiteral) expressionNode()      {}
+func (n *NullLiteral) TokenLiteral() string { return n.Token.Literal }
+func (n *NullLiteral) String() string       { return n.Token.Literal }
+
+// IfExpression is if (Condition) { Consequence }, optionally followed by
+// else { Alternative } or by else and another IfExpression, ElseIf. At most
+// one of Alternative and ElseIf is set.
 type IfExpression struct {
 	Token       token.Token
 	Condition   Expression
 	Consequence *BlockStatement
 	Alternative *BlockStatement
+	ElseIf      *IfExpression
 }
 
 func (ie *IfExpression) expressionNode()      {}
 func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
-	out.WriteString("if")
+	out.WriteString("if (")
 	out.WriteString(ie.Condition.String())
-	out.WriteString(" ")
-	out.WriteString(ie.Consequence.String())
-	if ie.Alternative != nil {
-		out.WriteString("else ")
-		out.WriteString(ie.Alternative.String())
+	out.WriteString(") ")
+	out.WriteString(braced(ie.Consequence))
+	switch {
+	case ie.ElseIf != nil:
+		out.WriteString(" else ")
+		out.WriteString(ie.ElseIf.String())
+	case ie.Alternative != nil:
+		out.WriteString(" else ")
+		out.WriteString(braced(ie.Alternative))
+	}
+	return out.String()
+}
+
+// braced writes a block the way it is written in source, in braces and
+// with a semicolon after every statement but the last, so that it parses
+// back to the same block.
+func braced(bs *BlockStatement) string {
+	parts := []string{}
+	for i, s := range bs.Statements {
+		str := s.String()
+		if i < len(bs.Statements)-1 && !strings.HasSuffix(str, ";") {
+			str += ";"
+		}
+		parts = append(parts, str)
 	}
+	if len(parts) == 0 {
+		return "{}"
+	}
+	return "{ " + strings.Join(parts, " ") + " }"
+}
+
+// ConditionalExpression is Condition ? Consequence : Alternative.
+type ConditionalExpression struct {
+	Token       token.Token
+	Condition   Expression
+	Consequence Expression
+	Alternative Expression
+}
+
+func (ce *ConditionalExpression) expressionNode()      {}
+func (ce *ConditionalExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *ConditionalExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(ce.Condition.String())
+	out.WriteString(" ? ")
+	out.WriteString(ce.Consequence.String())
+	out.WriteString(" : ")
+	out.WriteString(ce.Alternative.String())
+	out.WriteString(")")
 	return out.String()
 }
 
@@ -197,9 +297,16 @@ func (bs *BlockStatement) String() string {
 	return out.String()
 }
 
+// FunctionLiteral is fn(params) { body }, or fn name(params) { body }, which
+// also binds the function to name; arrow functions are parsed into one too.
+// Parameters are patterns, so arguments can be destructured and parameters
+// can have default values. Rest, if set, collects the arguments left over
+// after Parameters.
 type FunctionLiteral struct {
 	Token      token.Token
-	Parameters []*Identifier
+	Name       *Identifier
+	Parameters []Expression
+	Rest       *Identifier
 	Body       *BlockStatement
 }
 
@@ -212,7 +319,13 @@ func (fn *FunctionLiteral) String() string {
 	for _, p := range fn.Parameters {
 		params = append(params, p.String())
 	}
+	if fn.Rest != nil {
+		params = append(params, "..."+fn.Rest.String())
+	}
 	out.WriteString(fn.TokenLiteral())
+	if fn.Name != nil {
+		out.WriteString(" " + fn.Name.String())
+	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(") ")
@@ -287,6 +400,59 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+// SliceExpression is left[start:end] or left[start:end:step]. Omitted
+// bounds are nil.
+type SliceExpression struct {
+	Token token.Token
+	Left  Expression
+	Start Expression
+	End   Expression
+	Step  Expression
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+	bound := func(e Expression) {
+		if e != nil {
+			out.WriteString(e.String())
+		}
+	}
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	bound(se.Start)
+	out.WriteString(":")
+	bound(se.End)
+	if se.Step != nil {
+		out.WriteString(":")
+		bound(se.Step)
+	}
+	out.WriteString("])")
+	return out.String()
+}
+
+// MemberExpression is obj.property: a string-keyed hash lookup, a module
+// member, or, as the function of a call, a method call.
+type MemberExpression struct {
+	Token    token.Token
+	Object   Expression
+	Property *Identifier
+}
+
+func (me *MemberExpression) expressionNode()      {}
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(me.Object.String())
+	out.WriteString(".")
+	out.WriteString(me.Property.String())
+	out.WriteString(")")
+	return out.String()
+}
+
 type HashLiteral struct {
 	Token token.Token
 	Pairs map[Expression]Expression
@@ -309,6 +475,7 @@ func (hl *HashLiteral) String() string {
 type MacroLiteral struct {
 	Token      token.Token
 	Parameters []*Identifier
+	Rest       *Identifier
 	Body       *BlockStatement
 }
 
@@ -320,6 +487,9 @@ func (ml *MacroLiteral) String() string {
 	for _, p := range ml.Parameters {
 		params = append(params, p.String())
 	}
+	if ml.Rest != nil {
+		params = append(params, "..."+ml.Rest.String())
+	}
 	out.WriteString(ml.TokenLiteral())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
@@ -327,3 +497,258 @@ func (ml *MacroLiteral) String() string {
 	out.WriteString(ml.Body.String())
 	return out.String()
 }
+
+type ImportStatement struct {
+	Token token.Token
+	Path  *StringLiteral
+	Alias *Identifier
+	// Resolved is the absolute path of the imported file, filled in when
+	// the import is resolved during macro expansion.
+	Resolved string
+}
+
+func (is *ImportStatement) statementNode()       {}
+func (is *ImportStatement) TokenLiteral() string { return is.Token.Literal }
+func (is *ImportStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(is.TokenLiteral() + " ")
+	out.WriteString(strconv.Quote(is.Path.Value))
+	if is.Alias != nil {
+		out.WriteString(" as " + is.Alias.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+type ExportStatement struct {
+	Token     token.Token
+	Statement Statement
+}
+
+func (es *ExportStatement) statementNode()       {}
+func (es *ExportStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExportStatement) String() string {
+	return es.TokenLiteral() + " " + es.Statement.String()
+}
+
+type StructStatement struct {
+	Token  token.Token
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+func (ss *StructStatement) statementNode()       {}
+func (ss *StructStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *StructStatement) String() string {
+	var out bytes.Buffer
+	fields := []string{}
+	for _, f := range ss.Fields {
+		fields = append(fields, f.String())
+	}
+	out.WriteString(ss.TokenLiteral() + " ")
+	out.WriteString(ss.Name.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString(" }")
+	return out.String()
+}
+
+// AssignExpression stores Value into Target, which is an identifier, a
+// member expression or an index expression.
+type AssignExpression struct {
+	Token  token.Token
+	Target Expression
+	Value  Expression
+}
+
+func (ae *AssignExpression) expressionNode()      {}
+func (ae *AssignExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(ae.Target.String())
+	out.WriteString(" = ")
+	out.WriteString(ae.Value.String())
+	out.WriteString(")")
+	return out.String()
+}
+
+// ArrayPattern matches arrays element by element. Rest, if set, binds the
+// elements left over after Elements.
+type ArrayPattern struct {
+	Token    token.Token
+	Elements []Expression
+	Rest     *Identifier
+}
+
+func (ap *ArrayPattern) expressionNode()      {}
+func (ap *ArrayPattern) TokenLiteral() string { return ap.Token.Literal }
+func (ap *ArrayPattern) String() string {
+	elements := []string{}
+	for _, e := range ap.Elements {
+		elements = append(elements, e.String())
+	}
+	if ap.Rest != nil {
+		elements = append(elements, "..."+ap.Rest.String())
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+type HashPatternPair struct {
+	Key   Expression
+	Value Expression
+}
+
+// HashPattern matches hashes and structs that have all of its keys. A bare
+// identifier key such as {name} is shorthand for {"name": name}.
+type HashPattern struct {
+	Token token.Token
+	Pairs []*HashPatternPair
+}
+
+func (hp *HashPattern) expressionNode()      {}
+func (hp *HashPattern) TokenLiteral() string { return hp.Token.Literal }
+func (hp *HashPattern) String() string {
+	pairs := []string{}
+	for _, pair := range hp.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+type MatchArm struct {
+	Pattern Expression
+	Guard   Expression
+	Body    *BlockStatement
+}
+
+func (ma *MatchArm) String() string {
+	var out bytes.Buffer
+	out.WriteString(ma.Pattern.String())
+	if ma.Guard != nil {
+		out.WriteString(" if ")
+		out.WriteString(ma.Guard.String())
+	}
+	out.WriteString(" => ")
+	out.WriteString(ma.Body.String())
+	return out.String()
+}
+
+type MatchExpression struct {
+	Token   token.Token
+	Subject Expression
+	Arms    []*MatchArm
+}
+
+func (me *MatchExpression) expressionNode()      {}
+func (me *MatchExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MatchExpression) String() string {
+	arms := []string{}
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.String())
+	}
+	return "match (" + me.Subject.String() + ") { " + strings.Join(arms, ", ") + " }"
+}
+
+type ThrowStatement struct {
+	Token token.Token
+	Value Expression
+}
+
+func (ts *ThrowStatement) statementNode()       {}
+func (ts *ThrowStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *ThrowStatement) String() string {
+	return ts.TokenLiteral() + " " + ts.Value.String() + ";"
+}
+
+// TryExpression evaluates Block, running Catch with the error bound to
+// Param if it fails, and Finally in any case. Either Catch or Finally may be
+// nil, and Param may be nil if the error is not needed.
+type TryExpression struct {
+	Token   token.Token
+	Block   *BlockStatement
+	Param   *Identifier
+	Catch   *BlockStatement
+	Finally *BlockStatement
+}
+
+func (te *TryExpression) expressionNode()      {}
+func (te *TryExpression) TokenLiteral() string { return te.Token.Literal }
+func (te *TryExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("try { ")
+	out.WriteString(te.Block.String())
+	out.WriteString(" }")
+	if te.Catch != nil {
+		out.WriteString(" catch ")
+		if te.Param != nil {
+			out.WriteString("(" + te.Param.String() + ") ")
+		}
+		out.WriteString("{ ")
+		out.WriteString(te.Catch.String())
+		out.WriteString(" }")
+	}
+	if te.Finally != nil {
+		out.WriteString(" finally { ")
+		out.WriteString(te.Finally.String())
+		out.WriteString(" }")
+	}
+	return out.String()
+}
+
+// DefaultPattern binds Pattern to Default when there is no value to match,
+// as for a missing array element, hash key or argument.
+type DefaultPattern struct {
+	Token   token.Token
+	Pattern Expression
+	Default Expression
+}
+
+func (dp *DefaultPattern) expressionNode()      {}
+func (dp *DefaultPattern) TokenLiteral() string { return dp.Token.Literal }
+func (dp *DefaultPattern) String() string {
+	return dp.Pattern.String() + " = " + dp.Default.String()
+}
+
+// SpreadExpression passes the elements of an array as separate arguments
+// or array elements: f(...args), [0, ...rest].
+type SpreadExpression struct {
+	Token token.Token
+	Value Expression
+}
+
+func (se *SpreadExpression) expressionNode()      {}
+func (se *SpreadExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SpreadExpression) String() string       { return "..." + se.Value.String() }
+
+// NamedArgument passes Value to the parameter called Name: f(y: 2).
+type NamedArgument struct {
+	Token token.Token
+	Name  *Identifier
+	Value Expression
+}
+
+func (na *NamedArgument) expressionNode()      {}
+func (na *NamedArgument) TokenLiteral() string { return na.Token.Literal }
+func (na *NamedArgument) String() string       { return na.Name.String() + ": " + na.Value.String() }
+
+// InterpolatedString is a string literal with embedded expressions. Parts
+// alternates between StringLiterals for the text and the expressions.
+type InterpolatedString struct {
+	Token token.Token
+	Parts []Expression
+}
+
+func (is *InterpolatedString) expressionNode()      {}
+func (is *InterpolatedString) TokenLiteral() string { return is.Token.Literal }
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+	for _, part := range is.Parts {
+		if text, ok := part.(*StringLiteral); ok {
+			out.WriteString(text.String())
+		} else {
+			out.WriteString("${" + part.String() + "}")
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,34 @@
+package ast
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCopy(t *testing.T) {
+	original := &InfixExpression{
+		Left:     &IntegerLiteral{Value: 1},
+		Operator: "+",
+		Right: &CallExpression{
+			Function:  &Identifier{Value: "f"},
+			Arguments: []Expression{&IntegerLiteral{Value: 1}},
+		},
+	}
+	copied := Copy(original)
+	if !reflect.DeepEqual(original, copied) {
+		t.Fatalf("copy not equal. got=%#v, want=%#v", copied, original)
+	}
+	Modify(copied, func(node Node) Node {
+		if integer, ok := node.(*IntegerLiteral); ok {
+			integer.Value = 2
+		}
+		return node
+	})
+	if original.Left.(*IntegerLiteral).Value != 1 {
+		t.Errorf("modifying the copy changed the original left operand")
+	}
+	call := original.Right.(*CallExpression)
+	if call.Arguments[0].(*IntegerLiteral).Value != 1 {
+		t.Errorf("modifying the copy changed the original call arguments")
+	}
+}
@@ -2,53 +2,137 @@ package ast
 
 type ModifierFunc func(Node) Node
 
+// Modify walks the tree rooted at node depth-first, replacing every node
+// with the result of modifier after its children have been modified.
 func Modify(node Node, modifier ModifierFunc) Node {
+	ModifyChildren(node, func(child Node) Node {
+		return Modify(child, modifier)
+	})
+	return modifier(node)
+}
+
+// ModifyChildren replaces each direct child of node with the result of
+// modifier. It does not descend any further, so callers that need control
+// over the traversal order can recurse themselves.
+func ModifyChildren(node Node, modifier ModifierFunc) {
 	switch node := node.(type) {
 	case *Program:
 		for i, statement := range node.Statements {
-			node.Statements[i], _ = Modify(statement, modifier).(Statement)
+			node.Statements[i], _ = modifier(statement).(Statement)
 		}
 	case *ExpressionStatement:
-		node.Expression, _ = Modify(node.Expression, modifier).(Expression)
+		node.Expression, _ = modifier(node.Expression).(Expression)
 	case *InfixExpression:
-		node.Left, _ = Modify(node.Left, modifier).(Expression)
-		node.Right, _ = Modify(node.Right, modifier).(Expression)
+		node.Left, _ = modifier(node.Left).(Expression)
+		node.Right, _ = modifier(node.Right).(Expression)
+	case *PipeExpression:
+		node.Left, _ = modifier(node.Left).(Expression)
+		node.Right, _ = modifier(node.Right).(Expression)
+	case *MatchExpression:
+		node.Subject, _ = modifier(node.Subject).(Expression)
+		for _, arm := range node.Arms {
+			if arm.Guard != nil {
+				arm.Guard, _ = modifier(arm.Guard).(Expression)
+			}
+			arm.Body, _ = modifier(arm.Body).(*BlockStatement)
+		}
+	case *ThrowStatement:
+		node.Value, _ = modifier(node.Value).(Expression)
+	case *TryExpression:
+		node.Block, _ = modifier(node.Block).(*BlockStatement)
+		if node.Catch != nil {
+			node.Catch, _ = modifier(node.Catch).(*BlockStatement)
+		}
+		if node.Finally != nil {
+			node.Finally, _ = modifier(node.Finally).(*BlockStatement)
+		}
+	case *ArrayPattern:
+		for i, element := range node.Elements {
+			node.Elements[i], _ = modifier(element).(Expression)
+		}
+	case *HashPattern:
+		for _, pair := range node.Pairs {
+			pair.Value, _ = modifier(pair.Value).(Expression)
+		}
+	case *DefaultPattern:
+		node.Pattern, _ = modifier(node.Pattern).(Expression)
+		node.Default, _ = modifier(node.Default).(Expression)
+	case *InterpolatedString:
+		for i, part := range node.Parts {
+			node.Parts[i], _ = modifier(part).(Expression)
+		}
+	case *SpreadExpression:
+		node.Value, _ = modifier(node.Value).(Expression)
+	case *NamedArgument:
+		node.Value, _ = modifier(node.Value).(Expression)
+	case *AssignExpression:
+		node.Target, _ = modifier(node.Target).(Expression)
+		node.Value, _ = modifier(node.Value).(Expression)
 	case *PrefixExpression:
-		node.Right, _ = Modify(node.Right, modifier).(Expression)
+		node.Right, _ = modifier(node.Right).(Expression)
 	case *IndexExpression:
-		node.Index, _ = Modify(node.Index, modifier).(Expression)
-		node.Left, _ = Modify(node.Left, modifier).(Expression)
+		node.Index, _ = modifier(node.Index).(Expression)
+		node.Left, _ = modifier(node.Left).(Expression)
+	case *SliceExpression:
+		node.Left, _ = modifier(node.Left).(Expression)
+		if node.Start != nil {
+			node.Start, _ = modifier(node.Start).(Expression)
+		}
+		if node.End != nil {
+			node.End, _ = modifier(node.End).(Expression)
+		}
+		if node.Step != nil {
+			node.Step, _ = modifier(node.Step).(Expression)
+		}
+	case *MemberExpression:
+		node.Object, _ = modifier(node.Object).(Expression)
 	case *IfExpression:
-		node.Condition, _ = Modify(node.Condition, modifier).(Expression)
-		node.Consequence, _ = Modify(node.Consequence, modifier).(*BlockStatement)
+		node.Condition, _ = modifier(node.Condition).(Expression)
+		node.Consequence, _ = modifier(node.Consequence).(*BlockStatement)
 		if node.Alternative != nil {
-			node.Alternative, _ = Modify(node.Alternative, modifier).(*BlockStatement)
+			node.Alternative, _ = modifier(node.Alternative).(*BlockStatement)
 		}
+		if node.ElseIf != nil {
+			node.ElseIf, _ = modifier(node.ElseIf).(*IfExpression)
+		}
+	case *ConditionalExpression:
+		node.Condition, _ = modifier(node.Condition).(Expression)
+		node.Consequence, _ = modifier(node.Consequence).(Expression)
+		node.Alternative, _ = modifier(node.Alternative).(Expression)
 	case *BlockStatement:
 		for i, statement := range node.Statements {
-			node.Statements[i], _ = Modify(statement, modifier).(Statement)
+			node.Statements[i], _ = modifier(statement).(Statement)
 		}
 	case *ReturnStatement:
-		node.ReturnValue, _ = Modify(node.ReturnValue, modifier).(Expression)
+		node.ReturnValue, _ = modifier(node.ReturnValue).(Expression)
 	case *LetStatement:
-		node.Value, _ = Modify(node.Value, modifier).(Expression)
+		if node.Pattern != nil {
+			node.Pattern, _ = modifier(node.Pattern).(Expression)
+		}
+		node.Value, _ = modifier(node.Value).(Expression)
+	case *ExportStatement:
+		node.Statement, _ = modifier(node.Statement).(Statement)
 	case *FunctionLiteral:
 		for i, _ := range node.Parameters {
-			node.Parameters[i], _ = Modify(node.Parameters[i], modifier).(*Identifier)
+			node.Parameters[i], _ = modifier(node.Parameters[i]).(Expression)
+		}
+		node.Body, _ = modifier(node.Body).(*BlockStatement)
+	case *CallExpression:
+		node.Function, _ = modifier(node.Function).(Expression)
+		for i, _ := range node.Arguments {
+			node.Arguments[i], _ = modifier(node.Arguments[i]).(Expression)
 		}
-		node.Body, _ = Modify(node.Body, modifier).(*BlockStatement)
 	case *ArrayLiteral:
 		for i, _ := range node.Elements {
-			node.Elements[i], _ = Modify(node.Elements[i], modifier).(Expression)
+			node.Elements[i], _ = modifier(node.Elements[i]).(Expression)
 		}
 	case *HashLiteral:
 		newPairs := make(map[Expression]Expression)
 		for key, val := range node.Pairs {
-			nk, _ := Modify(key, modifier).(Expression)
-			nv, _ := Modify(val, modifier).(Expression)
+			nk, _ := modifier(key).(Expression)
+			nv, _ := modifier(val).(Expression)
 			newPairs[nk] = nv
 		}
 		node.Pairs = newPairs
 	}
-	return modifier(node)
 }
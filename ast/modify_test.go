@@ -55,6 +55,14 @@ func TestModify(t *testing.T) {
 			&IndexExpression{Left: one(), Index: one()},
 			&IndexExpression{Left: two(), Index: two()},
 		},
+		{
+			&PipeExpression{Left: one(), Right: one()},
+			&PipeExpression{Left: two(), Right: two()},
+		},
+		{
+			&SliceExpression{Left: one(), Start: one(), Step: one()},
+			&SliceExpression{Left: two(), Start: two(), Step: two()},
+		},
 		{
 			&IfExpression{
 				Condition: one(),
@@ -97,7 +105,7 @@ func TestModify(t *testing.T) {
 		},
 		{
 			&FunctionLiteral{
-				Parameters: []*Identifier{},
+				Parameters: []Expression{},
 				Body: &BlockStatement{
 					Statements: []Statement{
 						&ExpressionStatement{Expression: one()},
@@ -105,7 +113,7 @@ func TestModify(t *testing.T) {
 				},
 			},
 			&FunctionLiteral{
-				Parameters: []*Identifier{},
+				Parameters: []Expression{},
 				Body: &BlockStatement{
 					Statements: []Statement{
 						&ExpressionStatement{Expression: two()},
@@ -113,6 +121,20 @@ func TestModify(t *testing.T) {
 				},
 			},
 		},
+		{
+			&MemberExpression{Object: one(), Property: &Identifier{Value: "x"}},
+			&MemberExpression{Object: two(), Property: &Identifier{Value: "x"}},
+		},
+		{
+			&CallExpression{
+				Function:  &Identifier{Value: "f"},
+				Arguments: []Expression{one(), two()},
+			},
+			&CallExpression{
+				Function:  &Identifier{Value: "f"},
+				Arguments: []Expression{two(), two()},
+			},
+		},
 		{
 			&ArrayLiteral{
 				Elements: []Expression{one()},
@@ -0,0 +1,187 @@
+package eval
+
+import (
+	"fmt"
+	"learn-interpreter/ast"
+	"learn-interpreter/object"
+	"learn-interpreter/token"
+	"strings"
+)
+
+// astBuiltins let macro bodies inspect and build the ASTs held by Quote
+// objects instead of only splicing them with unquote.
+var astBuiltins = map[string]*object.Builtin{
+	"node_kind": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			node, err := quotedNodeArgument("node_kind", args)
+			if err != nil {
+				return err
+			}
+			return &object.String{Value: nodeKind(node)}
+		},
+	},
+	"node_children": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			node, err := quotedNodeArgument("node_children", args)
+			if err != nil {
+				return err
+			}
+			children := []object.Object{}
+			ast.ModifyChildren(ast.Copy(node), func(child ast.Node) ast.Node {
+				if child != nil {
+					children = append(children, &object.Quote{Node: child})
+				}
+				return child
+			})
+			return &object.Array{Elements: children}
+		},
+	},
+	"ident_name": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			node, err := quotedNodeArgument("ident_name", args)
+			if err != nil {
+				return err
+			}
+			ident, ok := node.(*ast.Identifier)
+			if !ok {
+				return newError("argument to `ident_name` must be Identifier, got %s", nodeKind(node))
+			}
+			return &object.String{Value: ident.Value}
+		},
+	},
+	"literal_value": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			node, err := quotedNodeArgument("literal_value", args)
+			if err != nil {
+				return err
+			}
+			switch node := node.(type) {
+			case *ast.IntegerLiteral, *ast.StringLiteral, *ast.BooleanLiteral, *ast.NullLiteral:
+				return Eval(node, object.NewEnvironment())
+			default:
+				return newError("argument to `literal_value` must be a literal, got %s", nodeKind(node))
+			}
+		},
+	},
+	"node_operator": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			node, err := quotedNodeArgument("node_operator", args)
+			if err != nil {
+				return err
+			}
+			switch node := node.(type) {
+			case *ast.PrefixExpression:
+				return &object.String{Value: node.Operator}
+			case *ast.InfixExpression:
+				return &object.String{Value: node.Operator}
+			default:
+				return newError("argument to `node_operator` must be an operator expression, got %s", nodeKind(node))
+			}
+		},
+	},
+	"make_ident": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			name, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `make_ident` must be STRING, got %s", args[0].Type())
+			}
+			t := token.Token{Type: token.IDENT, Literal: name.Value}
+			return &object.Quote{Node: &ast.Identifier{Token: t, Value: name.Value}}
+		},
+	},
+	"make_literal": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			node, err := convertObjectToASTNode(args[0])
+			if err != nil {
+				return err
+			}
+			return &object.Quote{Node: node}
+		},
+	},
+	"make_call": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			function, err := quotedExpression("make_call", args[0])
+			if err != nil {
+				return err
+			}
+			array, ok := args[1].(*object.Array)
+			if !ok {
+				return newError("second argument to `make_call` must be ARRAY, got %s", args[1].Type())
+			}
+			arguments := []ast.Expression{}
+			for _, el := range array.Elements {
+				arg, err := quotedExpression("make_call", el)
+				if err != nil {
+					return err
+				}
+				arguments = append(arguments, arg)
+			}
+			t := token.Token{Type: token.LPAREN, Literal: "("}
+			return &object.Quote{Node: &ast.CallExpression{Token: t, Function: function, Arguments: arguments}}
+		},
+	},
+	"make_infix": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 3 {
+				return newError("wrong number of arguments. got=%d, want=3", len(args))
+			}
+			left, err := quotedExpression("make_infix", args[0])
+			if err != nil {
+				return err
+			}
+			operator, ok := args[1].(*object.String)
+			if !ok {
+				return newError("second argument to `make_infix` must be STRING, got %s", args[1].Type())
+			}
+			right, err := quotedExpression("make_infix", args[2])
+			if err != nil {
+				return err
+			}
+			t := token.Token{Type: token.TokenType(operator.Value), Literal: operator.Value}
+			return &object.Quote{Node: &ast.InfixExpression{Token: t, Left: left, Operator: operator.Value, Right: right}}
+		},
+	},
+}
+
+func init() {
+	for name, builtin := range astBuiltins {
+		builtins[name] = builtin
+	}
+}
+
+// nodeKind names the type of node, e.g. "CallExpression".
+func nodeKind(node ast.Node) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast.")
+}
+
+func quotedNodeArgument(name string, args []object.Object) (ast.Node, *object.Error) {
+	if len(args) != 1 {
+		return nil, newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	quote, ok := args[0].(*object.Quote)
+	if !ok {
+		return nil, newError("argument to `%s` must be QUOTE, got %s", name, args[0].Type())
+	}
+	return quote.Node, nil
+}
+
+func quotedExpression(name string, obj object.Object) (ast.Expression, *object.Error) {
+	quote, ok := obj.(*object.Quote)
+	if !ok {
+		return nil, newError("argument to `%s` must be QUOTE, got %s", name, obj.Type())
+	}
+	exp, ok := ast.Copy(quote.Node).(ast.Expression)
+	if !ok {
+		return nil, newError("argument to `%s` must be an expression, got %s", name, nodeKind(quote.Node))
+	}
+	return exp, nil
+}
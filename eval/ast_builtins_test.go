@@ -0,0 +1,83 @@
+package eval
+
+import (
+	"learn-interpreter/object"
+	"testing"
+)
+
+func TestASTIntrospectionBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`node_kind(quote(1 + 2))`, "InfixExpression"},
+		{`node_kind(quote(f(x)))`, "CallExpression"},
+		{`node_kind(quote(x))`, "Identifier"},
+		{`len(node_children(quote(f(x, y))))`, 3},
+		{`node_kind(node_children(quote(-x))[0])`, "Identifier"},
+		{`ident_name(quote(foobar))`, "foobar"},
+		{`literal_value(quote(5))`, 5},
+		{`literal_value(quote("five"))`, "five"},
+		{`node_operator(quote(1 * 2))`, "*"},
+		{`node_operator(quote(!x))`, "!"},
+		{`ident_name(quote(1))`, "ERROR: argument to `ident_name` must be Identifier, got IntegerLiteral"},
+		{`node_kind(1)`, "ERROR: argument to `node_kind` must be QUOTE, got Integer"},
+		{`literal_value(quote(x))`, "ERROR: argument to `literal_value` must be a literal, got Identifier"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), expected)
+			}
+		}
+	}
+}
+
+func TestASTConstructorBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`make_ident("x")`, `x`},
+		{`make_call(quote(f), [quote(1), make_ident("y")])`, `f(1, y)`},
+		{`make_infix(quote(1), "+", make_literal(2))`, `(1 + 2)`},
+		{`make_literal([1, "a"])`, `[1, a]`},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		quote, ok := evaluated.(*object.Quote)
+		if !ok {
+			t.Fatalf("expected *object.Quote. got=%T (%+v)", evaluated, evaluated)
+		}
+		if quote.Node.String() != tt.expected {
+			t.Errorf("not equal. got=%q, want=%q", quote.Node.String(), tt.expected)
+		}
+	}
+}
+
+func TestPatternMatchingMacro(t *testing.T) {
+	input := `
+ let swap_infix = macro(exp) {
+ if (node_kind(exp) == "InfixExpression") {
+ let children = node_children(exp);
+ make_infix(children[1], node_operator(exp), children[0])
+ } else {
+ exp
+ }
+ };
+ [swap_infix(10 - 4), swap_infix(3)];
+ `
+	program := testParseProgram(input)
+	expanded, errs := ExpandMacros(program, object.NewEnvironment())
+	if len(errs) != 0 {
+		t.Fatalf("unexpected macro errors: %v", errs)
+	}
+	evaluated := Eval(expanded, object.NewEnvironment())
+	if evaluated.Inspect() != "[-6, 3]" {
+		t.Errorf("wrong result. got=%q", evaluated.Inspect())
+	}
+}
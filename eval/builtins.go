@@ -3,6 +3,7 @@ package eval
 import (
 	"fmt"
 	"learn-interpreter/object"
+	"unicode/utf8"
 )
 
 var builtins = map[string]*object.Builtin{
@@ -15,7 +16,7 @@ var builtins = map[string]*object.Builtin{
 			case *object.Array:
 				return &object.Integer{Value: int64(len(arg.Elements))}
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
 			default:
 				return newError("argument to `len` not supported, got %s", args[0].Type())
 			}
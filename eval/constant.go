@@ -0,0 +1,96 @@
+package eval
+
+import (
+	"learn-interpreter/ast"
+	"learn-interpreter/object"
+)
+
+func evalLetStatement(node *ast.LetStatement, env *object.Environment) object.Object {
+	names := []string{}
+	if node.Pattern != nil {
+		names = patternNames(node.Pattern)
+	} else {
+		names = append(names, node.Name.Value)
+	}
+	if err := checkDeclaration(env, names, node.IsConst()); err != nil {
+		return locate(err, node.Token)
+	}
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+	if node.Pattern != nil {
+		if err := destructure(node.Pattern, val, env); err != nil {
+			return locate(err, node.Token)
+		}
+	} else {
+		env.Set(node.Name.Value, val)
+	}
+	if node.IsConst() {
+		for _, name := range names {
+			bound, _ := env.Get(name)
+			env.SetConst(name, bound)
+		}
+	}
+	return nil
+}
+
+// checkDeclaration reports an error if names cannot be declared in env: a
+// constant cannot be declared again in the same scope, and a name already
+// declared there cannot be made a constant. Function bodies, catch blocks
+// and match arms have scopes of their own, which may shadow both; the
+// blocks of if and try share the scope around them.
+func checkDeclaration(env *object.Environment, names []string, constant bool) *object.Error {
+	for _, name := range names {
+		defined, isConst := env.Declared(name)
+		if isConst {
+			return newError("cannot redeclare constant %s", name)
+		}
+		if constant && defined {
+			return newError("cannot declare constant %s: %s is already declared in this scope", name, name)
+		}
+	}
+	return nil
+}
+
+// freeze marks value and every array, hash and struct inside it as frozen.
+func freeze(value object.Object) {
+	switch value := value.(type) {
+	case *object.Array:
+		if value.Frozen {
+			return
+		}
+		value.Frozen = true
+		for _, el := range value.Elements {
+			freeze(el)
+		}
+	case *object.Hash:
+		if value.Frozen {
+			return
+		}
+		value.Frozen = true
+		for _, pair := range value.Pairs {
+			freeze(pair.Value)
+		}
+	case *object.Struct:
+		if value.Frozen {
+			return
+		}
+		value.Frozen = true
+		for _, field := range value.Fields {
+			freeze(field)
+		}
+	}
+}
+
+func init() {
+	builtins["freeze"] = &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			freeze(args[0])
+			return args[0]
+		},
+	}
+}
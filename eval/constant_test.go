@@ -0,0 +1,67 @@
+package eval
+
+import (
+	"testing"
+)
+
+func TestConstants(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`const limit = 10; limit * 2`, "20"},
+		{`const limit = 10; limit = 11`, "ERROR: cannot assign to constant limit"},
+		{`const limit = 10; let f = fn() { limit = 11 }; f()`, "ERROR: cannot assign to constant limit"},
+		{`const limit = 10; let limit = 11`, "ERROR: cannot redeclare constant limit"},
+		{`const limit = 10; const limit = 11`, "ERROR: cannot redeclare constant limit"},
+		{`let limit = 10; const limit = 11`, "ERROR: cannot declare constant limit: limit is already declared in this scope"},
+		{`const limit = 10; let f = fn() { let limit = 1; limit = 2; limit }; [f(), limit]`, "[2, 10]"},
+		{`const limit = 10; let f = fn(limit) { limit }; f(3)`, "3"},
+		{`const x = 1; if (true) { let x = 2 }`, "ERROR: cannot redeclare constant x"},
+		{`let x = 1; if (true) { const x = 2 }`, "ERROR: cannot declare constant x: x is already declared in this scope"},
+		{`if (true) { const x = 2 }; x`, "2"},
+		{`const x = 1; try { throw 2 } catch (e) { let x = e.value; x }`, "2"},
+		{`const [a, b] = [1, 2]; a = 3`, "ERROR: cannot assign to constant a"},
+		{`const {x} = {"x": 1}; let x = 2`, "ERROR: cannot redeclare constant x"},
+		{`const f = 1; fn f() { 2 }`, "ERROR: cannot redeclare constant f"},
+		{`const Point = 1; struct Point { x }`, "ERROR: cannot redeclare constant Point"},
+		{`let x = 1; let x = 2; x = 3; x`, "3"},
+		{`const xs = [1, 2]; xs[0] = 5; xs`, "[5, 2]"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let xs = freeze([1, 2]); xs[0] = 5`, "ERROR: cannot modify frozen Array"},
+		{`let h = freeze({"a": 1}); h["a"] = 2`, "ERROR: cannot modify frozen Hash"},
+		{`let h = freeze({"a": 1}); h.b = 2`, "ERROR: cannot modify frozen Hash"},
+		{`let config = freeze({"ports": [80, 443]}); config.ports[0] = 8080`, "ERROR: cannot modify frozen Array"},
+		{`let xs = [[1], {"a": [2]}]; freeze(xs); xs[1]["a"][0] = 3`, "ERROR: cannot modify frozen Array"},
+		{`let xs = freeze([1, 2]); xs.push(3)`, "[1, 2, 3]"},
+		{`let xs = freeze([1, 2]); let ys = xs.push(3); ys[0] = 9; ys`, "[9, 2, 3]"},
+		{`let xs = [1]; xs[0] = xs; freeze(xs); len(xs)`, "1"},
+		{`struct P { x } let p = freeze(P(1)); p.x = 2`, "ERROR: cannot modify frozen P"},
+		{`struct S { arr } freeze([S([1])])[0].arr[0] = 9`, "ERROR: cannot modify frozen Array"},
+		{`struct S { arr } let xs = freeze([S([1])]); xs[0].arr = [2]`, "ERROR: cannot modify frozen S"},
+		{`struct P { x } let p = P(1); freeze([p]); p.x = 2`, "ERROR: cannot modify frozen P"},
+		{`struct P { x } let p = P(1); p.x = 2; p`, "P{x: 2}"},
+		{`freeze(5)`, "5"},
+		{`freeze()`, "ERROR: wrong number of arguments. got=0, want=1"},
+		{`try { freeze([1])[0] = 2 } catch (e) { e.kind }`, "RuntimeError"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
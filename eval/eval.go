@@ -1,9 +1,12 @@
 package eval
 
 import (
+	"bytes"
 	"fmt"
 	"learn-interpreter/ast"
 	"learn-interpreter/object"
+	"math"
+	"math/big"
 )
 
 var (
@@ -17,25 +20,29 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.Program:
 		return evalProgram(node.Statements, env)
 	case *ast.ExpressionStatement:
+		if fn, ok := node.Expression.(*ast.FunctionLiteral); ok && fn.Name != nil {
+			return evalFunctionDeclaration(fn, env)
+		}
 		return Eval(node.Expression, env)
 	case *ast.IntegerLiteral:
+		if node.Big != nil {
+			return &object.BigInt{Value: node.Big}
+		}
 		return &object.Integer{Value: node.Value}
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 	case *ast.BooleanLiteral:
 		return nativeBoolToBooleanObject(node.Value)
+	case *ast.NullLiteral:
+		return NULL
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
-		return evalPrefixExpression(node.Operator, right)
+		return locate(evalPrefixExpression(node.Operator, right), node.Token)
 	case *ast.LetStatement:
-		val := Eval(node.Value, env)
-		if isError(val) {
-			return val
-		}
-		env.Set(node.Name.Value, val)
+		return evalLetStatement(node, env)
 	case *ast.InfixExpression:
 		left := Eval(node.Left, env)
 		if isError(left) {
@@ -45,11 +52,20 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(right) {
 			return right
 		}
-		return evalInfixExpression(node.Operator, left, right)
+		return locate(evalInfixExpression(node.Operator, left, right), node.Token)
 	case *ast.BlockStatement:
 		return evalBlockStatement(node, env)
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
+	case *ast.ConditionalExpression:
+		condition := Eval(node.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+		if isTruthy(condition) {
+			return Eval(node.Consequence, env)
+		}
+		return Eval(node.Alternative, env)
 	case *ast.ReturnStatement:
 		val := Eval(node.ReturnValue, env)
 		if isError(val) {
@@ -57,24 +73,36 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 		return &object.ReturnValue{Value: val}
 	case *ast.Identifier:
-		return evalIdentifier(node, env)
+		return locate(evalIdentifier(node, env), node.Token)
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{Parameters: params, Body: body, Env: env}
+		fn := &object.Function{Parameters: params, Rest: node.Rest, Body: body, Env: env}
+		if node.Name != nil {
+			// A named function expression can call itself by its name,
+			// which is not visible to the code around it.
+			fn.Env = object.NewEnclosedEnvironment(env)
+			fn.Env.Set(node.Name.Value, fn)
+		}
+		return fn
+	case *ast.PipeExpression:
+		return Eval(node.Call(), env)
 	case *ast.CallExpression:
 		if node.Function.TokenLiteral() == "quote" {
 			return quote(node.Arguments[0], env)
 		}
+		if member, ok := node.Function.(*ast.MemberExpression); ok {
+			return locate(evalMethodCall(member, node.Arguments, env), node.Token)
+		}
 		function := Eval(node.Function, env)
 		if isError(function) {
 			return function
 		}
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
+		args, named, err := evalArguments(node.Arguments, env)
+		if err != nil {
+			return err
 		}
-		return applyFunction(function, args)
+		return locate(callFunction(function, args, named), node.Token)
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
@@ -90,9 +118,33 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(index) {
 			return index
 		}
-		return evalIndexExpression(left, index)
+		return locate(evalIndexExpression(left, index), node.Token)
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
+	case *ast.InterpolatedString:
+		return evalInterpolatedString(node, env)
+	case *ast.SliceExpression:
+		return locate(evalSliceExpression(node, env), node.Token)
+	case *ast.MemberExpression:
+		return locate(evalMemberExpression(node, env), node.Token)
+	case *ast.ImportStatement:
+		return evalImportStatement(node, env)
+	case *ast.ExportStatement:
+		return Eval(node.Statement, env)
+	case *ast.StructStatement:
+		return evalStructStatement(node, env)
+	case *ast.AssignExpression:
+		return locate(evalAssignExpression(node, env), node.Token)
+	case *ast.MatchExpression:
+		return locate(evalMatchExpression(node, env), node.Token)
+	case *ast.SpreadExpression:
+		return locate(newError("spread is only allowed in argument lists and array literals"), node.Token)
+	case *ast.NamedArgument:
+		return locate(newError("named argument %s is only allowed in argument lists", node.Name.Value), node.Token)
+	case *ast.ThrowStatement:
+		return evalThrowStatement(node, env)
+	case *ast.TryExpression:
+		return evalTryExpression(node, env)
 	}
 	return nil
 }
@@ -157,21 +209,31 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.OBJ_TYPE_INTEGER {
+	switch right := right.(type) {
+	case *object.Integer:
+		if right.Value == math.MinInt64 {
+			return newInteger(new(big.Int).Neg(big.NewInt(right.Value)))
+		}
+		return &object.Integer{Value: -right.Value}
+	case *object.BigInt:
+		return newInteger(new(big.Int).Neg(right.Value))
+	default:
 		return newError("unknown operator: -%s", right.Type())
 	}
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
 }
 
 func evalInfixExpression(operator string, left, right object.Object) object.Object {
 	switch {
 	case left.Type() == object.OBJ_TYPE_INTEGER && right.Type() == object.OBJ_TYPE_INTEGER:
 		return evalIntegerInfixExpression(operator, left, right)
+	case isInteger(left) && isInteger(right):
+		return evalBigIntInfixExpression(operator, left, right)
 	case left.Type() == object.OBJ_TYPE_BOOLEAN && right.Type() == object.OBJ_TYPE_BOOLEAN:
 		return evalBooleanInfixExpression(operator, left, right)
 	case left.Type() == object.OBJ_TYPE_STRING && right.Type() == object.OBJ_TYPE_STRING:
 		return evalStringInfixExpression(operator, left, right)
+	case left.Type() == object.OBJ_TYPE_STRUCT && right.Type() == object.OBJ_TYPE_STRUCT:
+		return evalStructInfixExpression(operator, left, right)
 	case left.Type() != right.Type():
 		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	default:
@@ -179,31 +241,6 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
 	}
 }
 
-func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
-	leftVal := left.(*object.Integer).Value
-	rightVal := right.(*object.Integer).Value
-	switch operator {
-	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
-	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
-	case "/":
-		return &object.Integer{Value: leftVal / rightVal}
-	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
-	case ">":
-		return nativeBoolToBooleanObject(leftVal > rightVal)
-	case "<":
-		return nativeBoolToBooleanObject(leftVal < rightVal)
-	case "==":
-		return nativeBoolToBooleanObject(leftVal == rightVal)
-	case "!=":
-		return nativeBoolToBooleanObject(leftVal != rightVal)
-	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
-	}
-}
-
 func evalBooleanInfixExpression(operator string, left, right object.Object) object.Object {
 	switch operator {
 	case "==":
@@ -230,6 +267,21 @@ func evalStringInfixExpression(operator string, left, right object.Object) objec
 	}
 }
 
+func evalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+	var out bytes.Buffer
+	for _, part := range node.Parts {
+		value := Eval(part, env)
+		if isError(value) {
+			return value
+		}
+		if value == nil {
+			value = NULL
+		}
+		out.WriteString(value.Inspect())
+	}
+	return &object.String{Value: out.String()}
+}
+
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(ie.Condition, env)
 	if isError(condition) {
@@ -237,6 +289,8 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Obje
 	}
 	if isTruthy(condition) {
 		return Eval(ie.Consequence, env)
+	} else if ie.ElseIf != nil {
+		return evalIfExpression(ie.ElseIf, env)
 	} else if ie.Alternative != nil {
 		return Eval(ie.Alternative, env)
 	} else {
@@ -258,7 +312,7 @@ func isTruthy(obj object.Object) bool {
 }
 
 func newError(format string, a ...interface{}) *object.Error {
-	return &object.Error{Message: fmt.Sprintf(format, a...)}
+	return &object.Error{Message: fmt.Sprintf(format, a...), Kind: object.ERROR_KIND_RUNTIME}
 }
 
 func isError(obj object.Object) bool {
@@ -276,6 +330,9 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 	if builtin, ok := builtins[node.Value]; ok {
 		return builtin
 	}
+	if isMacroexpandName(node.Value) {
+		return macroexpandBuiltin(node.Value, env)
+	}
 	return newError("identifier not found: " + node.Value)
 }
 
@@ -283,6 +340,14 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 	var result []object.Object
 
 	for _, exp := range exps {
+		if spread, ok := exp.(*ast.SpreadExpression); ok {
+			elements, err := evalSpread(spread, env)
+			if err != nil {
+				return []object.Object{err}
+			}
+			result = append(result, elements...)
+			continue
+		}
 		evaluated := Eval(exp, env)
 		if isError(evaluated) {
 			return []object.Object{evaluated}
@@ -292,25 +357,182 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 	return result
 }
 
+func evalSpread(node *ast.SpreadExpression, env *object.Environment) ([]object.Object, object.Object) {
+	value := Eval(node.Value, env)
+	if isError(value) {
+		return nil, value
+	}
+	array, ok := value.(*object.Array)
+	if !ok {
+		return nil, locate(newError("cannot spread %s, want Array", value.Type()), node.Token)
+	}
+	return array.Elements, nil
+}
+
+// evalArguments evaluates the arguments of a call in order, separating
+// named arguments from positional ones and expanding spread arguments.
+func evalArguments(exps []ast.Expression, env *object.Environment) ([]object.Object, map[string]object.Object, object.Object) {
+	args := []object.Object{}
+	var named map[string]object.Object
+	for _, exp := range exps {
+		switch exp := exp.(type) {
+		case *ast.NamedArgument:
+			value := Eval(exp.Value, env)
+			if isError(value) {
+				return nil, nil, value
+			}
+			if named == nil {
+				named = make(map[string]object.Object)
+			}
+			if _, ok := named[exp.Name.Value]; ok {
+				return nil, nil, locate(newError("argument %s given twice", exp.Name.Value), exp.Token)
+			}
+			named[exp.Name.Value] = value
+		case *ast.SpreadExpression:
+			elements, err := evalSpread(exp, env)
+			if err != nil {
+				return nil, nil, err
+			}
+			args = append(args, elements...)
+		default:
+			value := Eval(exp, env)
+			if isError(value) {
+				return nil, nil, value
+			}
+			args = append(args, value)
+		}
+	}
+	return args, named, nil
+}
+
+// evalFunctionDeclaration evaluates a named function standing as a statement
+// of its own, binding it to its name in env.
+func evalFunctionDeclaration(node *ast.FunctionLiteral, env *object.Environment) object.Object {
+	if err := checkDeclaration(env, []string{node.Name.Value}, false); err != nil {
+		return locate(err, node.Token)
+	}
+	fn := &object.Function{Parameters: node.Parameters, Rest: node.Rest, Body: node.Body, Env: env}
+	return env.Set(node.Name.Value, fn)
+}
+
 func applyFunction(fn object.Object, args []object.Object) object.Object {
+	return callFunction(fn, args, nil)
+}
+
+// callFunction calls fn with positional args and, for functions and struct
+// constructors, arguments passed by name.
+func callFunction(fn object.Object, args []object.Object, named map[string]object.Object) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
-		extendedEnv := extendFunctionEnv(fn, args)
+		extendedEnv, err := extendFunctionEnv(fn, args, named)
+		if err != nil {
+			return err
+		}
 		evaluated := Eval(fn.Body, extendedEnv)
 		return unwrapReturnValue(evaluated)
 	case *object.Builtin:
+		if len(named) > 0 {
+			return newError("builtin functions do not accept named arguments")
+		}
 		return fn.Fn(args...)
+	case *object.StructType:
+		return newStruct(fn, args, named)
 	default:
 		return newError("not a function: %s", fn.Type())
 	}
 }
 
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+// extendFunctionEnv binds the arguments of a call to the parameter patterns
+// of fn. Parameters without a positional argument are bound by name, or
+// else take their default value. Extra positional arguments are collected
+// into the rest parameter.
+func extendFunctionEnv(fn *object.Function, args []object.Object, named map[string]object.Object) (*object.Environment, *object.Error) {
+	if err := checkArity(fn, len(args), len(named) > 0); err != nil {
+		return nil, err
+	}
 	env := object.NewEnclosedEnvironment(fn.Env)
+	for name := range named {
+		idx := parameterIndex(fn, name)
+		if idx < 0 {
+			return nil, newError("unknown parameter %s", name)
+		}
+		if idx < len(args) {
+			return nil, newError("argument %s given twice", name)
+		}
+	}
 	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx])
+		var arg object.Object
+		if paramIdx < len(args) {
+			arg = args[paramIdx]
+		} else if value, ok := named[parameterName(param)]; ok {
+			arg = value
+		}
+		if arg != nil {
+			if err := destructure(param, arg, env); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		mismatch, err := bindMissing(param, env, "missing argument for parameter "+param.String())
+		if err != nil {
+			return nil, err
+		}
+		if mismatch != "" {
+			return nil, newError("%s", mismatch)
+		}
+	}
+	if fn.Rest != nil {
+		rest := []object.Object{}
+		if len(args) > len(fn.Parameters) {
+			rest = append(rest, args[len(fn.Parameters):]...)
+		}
+		env.Set(fn.Rest.Value, &object.Array{Elements: rest})
 	}
-	return env
+	return env, nil
+}
+
+// checkArity reports a call with too many positional arguments, or with
+// too few when there are no named arguments to make up for them.
+func checkArity(fn *object.Function, got int, hasNamed bool) *object.Error {
+	required := 0
+	for i, param := range fn.Parameters {
+		if _, ok := param.(*ast.DefaultPattern); !ok {
+			required = i + 1
+		}
+	}
+	max := len(fn.Parameters)
+	if (got >= required || hasNamed) && (got <= max || fn.Rest != nil) {
+		return nil
+	}
+	switch {
+	case fn.Rest != nil:
+		return newError("wrong number of arguments. got=%d, want at least %d", got, required)
+	case required == max:
+		return newError("wrong number of arguments. got=%d, want=%d", got, max)
+	default:
+		return newError("wrong number of arguments. got=%d, want %d to %d", got, required, max)
+	}
+}
+
+// parameterName is the name a parameter can be passed by, or "" if it is a
+// destructuring pattern.
+func parameterName(param ast.Expression) string {
+	if def, ok := param.(*ast.DefaultPattern); ok {
+		param = def.Pattern
+	}
+	if ident, ok := param.(*ast.Identifier); ok {
+		return ident.Value
+	}
+	return ""
+}
+
+func parameterIndex(fn *object.Function, name string) int {
+	for i, param := range fn.Parameters {
+		if parameterName(param) == name {
+			return i
+		}
+	}
+	return -1
 }
 
 func unwrapReturnValue(obj object.Object) object.Object {
@@ -324,8 +546,12 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.OBJ_TYPE_ARRAY && index.Type() == object.OBJ_TYPE_INTEGER:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.OBJ_TYPE_STRING && index.Type() == object.OBJ_TYPE_INTEGER:
+		return evalStringIndexExpression(left, index)
 	case left.Type() == object.OBJ_TYPE_HASH:
 		return evalHashIndexExpression(left, index)
+	case left.Type() == object.OBJ_TYPE_MODULE:
+		return evalModuleIndexExpression(left, index)
 	default:
 		return newError("index operator not supported: %s", left.Type())
 	}
@@ -333,9 +559,8 @@ func evalIndexExpression(left, index object.Object) object.Object {
 
 func evalArrayIndexExpression(array, index object.Object) object.Object {
 	arrayObject := array.(*object.Array)
-	idx := index.(*object.Integer).Value
-	maxIdx := int64(len(arrayObject.Elements) - 1)
-	if idx < 0 || idx > maxIdx {
+	idx, ok := normalizeIndex(index.(*object.Integer).Value, len(arrayObject.Elements))
+	if !ok {
 		return NULL
 	}
 	return arrayObject.Elements[idx]
@@ -34,6 +34,36 @@ func TestEvalIntegerExpression(t *testing.T) {
 	}
 }
 
+func TestIntegerOverflow(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"4294967296 * 4294967296", "18446744073709551616"},
+		{"-(-9223372036854775807 - 1)", "9223372036854775808"},
+		{"(-9223372036854775807 - 1) / -1", "9223372036854775808"},
+		{"18446744073709551616 / 4294967296", "4294967296"},
+		{"type(18446744073709551616 - 18446744073709551615)", "Integer"},
+		{"type(18446744073709551616)", "BigInt"},
+		{"18446744073709551616 > 1", "true"},
+		{"-18446744073709551616 < -1", "true"},
+		{"18446744073709551616 == 0x1_0000_0000_0000_0000", "true"},
+		{"0xFF + 0b1 + 0o7 + 1_000", "1263"},
+		{"1 / 0", "ERROR: division by zero"},
+		{"18446744073709551616 / 0", "ERROR: division by zero"},
+		{`18446744073709551616 + "a"`, "ERROR: type mismatch: BigInt + String"},
+		{`let h = {18446744073709551616: "big"}; h[9223372036854775807 * 2 + 2]`, "big"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
 func testEval(input string) object.Object {
 	l := lexer.New(input)
 	p := parser.New(l)
@@ -130,6 +160,8 @@ func TestIfElseExpressions(t *testing.T) {
 		{"if (1 > 2) { 10 }", nil},
 		{"if (1 > 2) { 10 } else { 20 }", 20},
 		{"if (1 < 2) { 10 } else { 20 }", 10},
+		{"if (null) { 10 }", nil},
+		{"if (1 < 2) { null } else { 20 }", nil},
 	}
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
@@ -296,6 +328,22 @@ func TestClosures(t *testing.T) {
 	testIntegerObject(t, testEval(input), 4)
 }
 
+func TestUnicodeIdentifiers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let größe = 3; größe * 2", 6},
+		{"let 名前 = fn(数) { 数 + 1 }; 名前(1)", 2},
+		{"let caf\u00e9 = 5; cafe\u0301", 5},
+		{"let cafe\u0301 = 7; caf\u00e9", 7},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
 func TestString(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -320,6 +368,28 @@ func TestString(t *testing.T) {
 	}
 }
 
+func TestStringInterpolation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let name = "Ann"; "Hello ${name}!"`, "Hello Ann!"},
+		{`let items = [1, 2]; "you have ${len(items)} items"`, "you have 2 items"},
+		{`"${1 + 2}${true}${null}"`, "3truenull"},
+		{`"list: ${[1, "a"]}"`, "list: [1, a]"},
+		{`let x = 1; "outer ${"inner ${x + 1}"}"`, "outer inner 2"},
+		{`"${ {"a": 1}["a"] }"`, "1"},
+		{`"cost: \${5}"`, "cost: ${5}"},
+		{`"${missing}"`, "ERROR: identifier not found: missing"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
 func TestStringConcatenation(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -433,6 +503,14 @@ func TestArrayIndexExpressions(t *testing.T) {
 		},
 		{
 			"[1, 2, 3][-1]",
+			3,
+		},
+		{
+			"[1, 2, 3][-3]",
+			1,
+		},
+		{
+			"[1, 2, 3][-4]",
 			nil,
 		},
 	}
@@ -581,3 +659,120 @@ func TestHashIndexExpressions(t *testing.T) {
 		}
 	}
 }
+
+func TestArrowFunctionsAndDeclarations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let double = x => x * 2; double(21)`, "42"},
+		{`let add = (a, b) => a + b; add(1, 2)`, "3"},
+		{`(() => 7)()`, "7"},
+		{`[1, 2, 3].map(x => x * x)`, "[1, 4, 9]"},
+		{`[1, 2, 3, 4].filter(x => x > 2).reduce((acc, x) => acc + x, 0)`, "7"},
+		{`let f = (x, y = 10) => x + y; f(1)`, "11"},
+		{`let f = ([a, b], ...rest) => [b, a, rest]; f([1, 2], 3)`, "[2, 1, [3]]"},
+		{`let adder = x => y => x + y; adder(1)(2)`, "3"},
+		{`[1, 2] |> (xs => len(xs))`, "2"},
+		{`fn add(a, b) { a + b } add(2, 3)`, "5"},
+		{`fn fact(n) { if (n < 2) { 1 } else { n * fact(n - 1) } } fact(10)`, "3628800"},
+		{`let f = fn() { fn inner() { 1 } inner() }; f()`, "1"},
+		{`let f = fn() { fn inner() { 1 } 2 }; f(); inner`, "ERROR: identifier not found: inner"},
+		{`let f = fn g(n) { n }; g(3)`, "ERROR: identifier not found: g"},
+		{`let fact = fn f(n) { n < 2 ? 1 : n * f(n - 1) }; fact(5)`, "120"},
+		{`[1, 2, 3].map(fn sq(x) { x * x })`, "[1, 4, 9]"},
+		{`let g = 1; let f = fn g() { g }; [g, type(f())]`, "[1, Function]"},
+		{`const g = 1; let f = fn g() { 2 }; [g, f()]`, "[1, 2]"},
+		{`const g = 1; fn g() { 2 }`, "ERROR: cannot redeclare constant g"},
+		{`match (3) { n if [1, 2, 3].filter(x => x == n).len() == 1 => "found", _ => "no" }`, "found"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestElseIfAndConditionalExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let sign = fn(n) { if (n < 0) { "-" } else if (n == 0) { "0" } else { "+" } }; [sign(-2), sign(0), sign(2)]`, "[-, 0, +]"},
+		{`if (false) { 1 } else if (false) { 2 }`, "null"},
+		{`if (1 > 2) { 1 } else if (2 > 1) { 2 } else { 3 }`, "2"},
+		{`true ? 1 : 2`, "1"},
+		{`null ? 1 : 2`, "2"},
+		{`let n = 5; n > 3 ? "big" : n > 1 ? "medium" : "small"`, "big"},
+		{`let n = 2; n > 3 ? "big" : n > 1 ? "medium" : "small"`, "medium"},
+		{`let x = 0; let y = true ? 1 : missing; y`, "1"},
+		{`[1, 2, 3].map(x => x > 1 ? x * 10 : x)`, "[1, 20, 30]"},
+		{`missing ? 1 : 2`, "ERROR: identifier not found: missing"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestPipeOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1, 2, 3] |> len`, "3"},
+		{`let add = fn(x, y) { x + y }; 1 |> add(2) |> add(3)`, "6"},
+		{`let sub = fn(x, y) { x - y }; 10 |> sub(3)`, "7"},
+		{`[1, 2, 3, 4] |> fn(xs) { xs.filter(fn(x) { x > 2 }) } |> len`, "2"},
+		{`let f = fn(x, ...rest) { [x, rest] }; 0 |> f(...[1, 2])`, "[0, [1, 2]]"},
+		{`let f = fn(x, y = 1, z = 2) { [x, y, z] }; 0 |> f(z: 5)`, "[0, 1, 5]"},
+		{`"a,b" |> split(",")`, "ERROR: identifier not found: split"},
+		{`let h = {"inc": fn(x) { x + 1 }}; 1 |> h.inc()`, "2"},
+		{`1 |> 2`, "ERROR: not a function: Integer"},
+		{`let r = 2 |> fn(x) { x * x }; r`, "4"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestFunctionArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let f = fn(x, y) { x + y }; f(1)`, "ERROR: wrong number of arguments. got=1, want=2"},
+		{`let f = fn(x) { x }; f(1, 2)`, "ERROR: wrong number of arguments. got=2, want=1"},
+		{`let f = fn(x, y = 10) { x + y }; f(1)`, "11"},
+		{`let f = fn(x, y = 10) { x + y }; f()`, "ERROR: wrong number of arguments. got=0, want 1 to 2"},
+		{`let f = fn(first, ...others) { [first, others] }; f(1, 2, 3)`, "[1, [2, 3]]"},
+		{`let f = fn(first, ...others) { others }; f(1)`, "[]"},
+		{`let f = fn(first, ...others) { others }; f()`, "ERROR: wrong number of arguments. got=0, want at least 1"},
+		{`let f = fn(x, y, z) { x + y + z }; f(...[1, 2, 3])`, "6"},
+		{`let f = fn(...all) { all }; f(0, ...[1, 2], 3)`, "[0, 1, 2, 3]"},
+		{`[0, ...[1, 2], 3]`, "[0, 1, 2, 3]"},
+		{`let f = fn(x) { x }; f(...1)`, "ERROR: cannot spread Integer, want Array"},
+		{`len(...["abc"])`, "3"},
+		{`let f = fn(x, y = 2, z = 3) { [x, y, z] }; f(1, z: 30)`, "[1, 2, 30]"},
+		{`let f = fn(x, y) { x - y }; f(y: 1, x: 10)`, "9"},
+		{`let f = fn(x) { x }; f(w: 1)`, "ERROR: unknown parameter w"},
+		{`let f = fn(x) { x }; f(1, x: 2)`, "ERROR: argument x given twice"},
+		{`let f = fn(x, y) { x }; f(y: 2)`, "ERROR: missing argument for parameter x"},
+		{`len(s: "a")`, "ERROR: builtin functions do not accept named arguments"},
+		{`...[1]`, "ERROR: spread is only allowed in argument lists and array literals"},
+		{`struct Point { x, y } Point(y: 2, x: 1)`, "Point{x: 1, y: 2}"},
+		{`let h = {"add": fn(a, b = 1) { a + b }}; h.add(a: 4)`, "5"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
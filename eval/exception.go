@@ -0,0 +1,96 @@
+package eval
+
+import (
+	"learn-interpreter/ast"
+	"learn-interpreter/object"
+	"learn-interpreter/token"
+)
+
+// evalThrowStatement turns its operand into an error. Throwing a hash with
+// a "message" key, such as one bound by catch, keeps its message and kind.
+func evalThrowStatement(node *ast.ThrowStatement, env *object.Environment) object.Object {
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+	err := &object.Error{Message: val.Inspect(), Kind: object.ERROR_KIND_THROWN, Value: val}
+	if hash, ok := val.(*object.Hash); ok {
+		if message, ok := hashString(hash, "message"); ok {
+			err.Message = message
+		}
+		if kind, ok := hashString(hash, "kind"); ok {
+			err.Kind = kind
+		}
+	}
+	return locate(err, node.Token)
+}
+
+func evalTryExpression(node *ast.TryExpression, env *object.Environment) object.Object {
+	result := Eval(node.Block, env)
+	if err, ok := result.(*object.Error); ok && node.Catch != nil {
+		catchEnv := object.NewEnclosedEnvironment(env)
+		if node.Param != nil {
+			catchEnv.Set(node.Param.Value, errorToHash(err))
+		}
+		result = Eval(node.Catch, catchEnv)
+	}
+	if node.Finally != nil {
+		finally := Eval(node.Finally, env)
+		if finally != nil {
+			rt := finally.Type()
+			if rt == object.OBJ_TYPE_RETURN_VALUE || rt == object.OBJ_TYPE_ERROR {
+				return finally
+			}
+		}
+	}
+	if result == nil {
+		return NULL
+	}
+	return result
+}
+
+// errorToHash exposes a caught error to eslang code.
+func errorToHash(err *object.Error) *object.Hash {
+	kind := err.Kind
+	if kind == "" {
+		kind = object.ERROR_KIND_RUNTIME
+	}
+	var value object.Object = &object.String{Value: err.Message}
+	if err.Value != nil {
+		value = err.Value
+	}
+	hash := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair)}
+	setHashString(hash, "message", &object.String{Value: err.Message})
+	setHashString(hash, "kind", &object.String{Value: kind})
+	setHashString(hash, "line", &object.Integer{Value: int64(err.Line)})
+	setHashString(hash, "column", &object.Integer{Value: int64(err.Column)})
+	setHashString(hash, "value", value)
+	return hash
+}
+
+func setHashString(hash *object.Hash, key string, value object.Object) {
+	k := &object.String{Value: key}
+	hash.Pairs[k.HashKey()] = object.HashPair{Key: k, Value: value}
+}
+
+func hashString(hash *object.Hash, key string) (string, bool) {
+	pair, ok := hash.Pairs[(&object.String{Value: key}).HashKey()]
+	if !ok {
+		return "", false
+	}
+	s, ok := pair.Value.(*object.String)
+	if !ok {
+		return "", false
+	}
+	return s.Value, true
+}
+
+// locate records the position of tok on obj if it is an error without one,
+// so that errors point at the innermost expression that failed.
+func locate(obj object.Object, tok token.Token) object.Object {
+	if err, ok := obj.(*object.Error); ok && err.Column == 0 {
+		err.Line = tok.Line
+		err.Column = tok.Column
+	}
+	return obj
+}
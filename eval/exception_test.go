@@ -0,0 +1,39 @@
+package eval
+
+import (
+	"testing"
+)
+
+func TestThrowAndCatch(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`throw "boom"`, "ERROR: boom"},
+		{`let f = fn() { throw "boom"; 1 }; f(); 2`, "ERROR: boom"},
+		{`try { throw "boom" } catch (e) { e.message }`, "boom"},
+		{`try { throw "boom" } catch (e) { e.kind }`, "Error"},
+		{`try { throw 42 } catch (e) { e.value + 1 }`, "43"},
+		{`try { throw {"message": "bad input", "kind": "ValueError"} } catch (e) { e.kind + ": " + e.message }`, "ValueError: bad input"},
+		{`try { 1 + true } catch (e) { e.kind + ": " + e.message }`, "RuntimeError: type mismatch: Integer + Boolean"},
+		{`try { len(1, 2) } catch (e) { e.message }`, "wrong number of arguments. got=2, want=1"},
+		{`try { missing } catch (e) { [e.line, e.column] }`, "[1, 7]"},
+		{"try {\n  1;\n  -true\n} catch (e) { [e.line, e.column] }", "[3, 3]"},
+		{`try { 5 } catch (e) { 0 }`, "5"},
+		{`try { throw "x" } catch { "caught" }`, "caught"},
+		{`try { throw "inner" } catch (e) { throw e }`, "ERROR: inner"},
+		{`try { try { throw "inner" } catch (e) { throw "outer" } } catch (e) { e.message }`, "outer"},
+		{`let log = []; try { log = push(log, 1) } finally { log = push(log, 2) }; log`, "[1, 2]"},
+		{`let log = []; try { throw "x" } catch (e) { log = push(log, e.message) } finally { log = push(log, "done") }; log`, "[x, done]"},
+		{`let log = []; try { try { throw "x" } finally { log = push(log, "done") } } catch { 0 }; log`, "[done]"},
+		{`try { throw "x" } finally { 1 }`, "ERROR: x"},
+		{`let f = fn() { try { return 1 } finally { 2 } }; f()`, "1"},
+		{`try { throw "x" } catch (e) { 1 } finally { throw "y" }`, "ERROR: y"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
@@ -1,13 +1,48 @@
 package eval
 
 import (
+	"fmt"
+	"io"
 	"learn-interpreter/ast"
 	"learn-interpreter/object"
+	"learn-interpreter/token"
 )
 
+const (
+	MACRO_ERR_ARITY       = "ArityMismatch"
+	MACRO_ERR_RETURN_TYPE = "BadReturnType"
+	MACRO_ERR_RUNTIME     = "RuntimeError"
+	MACRO_ERR_DEPTH       = "ExpansionDepthExceeded"
+	MACRO_ERR_IMPORT      = "ImportError"
+)
+
+// DefaultMaxExpansionDepth is how many nested expansions a single call site
+// may go through before the expander assumes the macro recurses forever.
+const DefaultMaxExpansionDepth = 100
+
+// MacroError describes a macro call that could not be expanded.
+type MacroError struct {
+	Kind    string
+	Macro   string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *MacroError) Error() string {
+	if e.Macro == "" {
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("%d:%d: macro %s: %s", e.Line, e.Column, e.Macro, e.Message)
+}
+
 func DefineMacros(program *ast.Program, env *object.Environment) {
+	program.Statements = defineMacros(program.Statements, env)
+}
+
+func defineMacros(statements []ast.Statement, env *object.Environment) []ast.Statement {
 	definitions := []int{}
-	for i, statement := range program.Statements {
+	for i, statement := range statements {
 		if isMacroDefinition(statement) {
 			addMacro(statement, env)
 			definitions = append(definitions, i)
@@ -15,16 +50,20 @@ func DefineMacros(program *ast.Program, env *object.Environment) {
 	}
 	for i := len(definitions) - 1; i >= 0; i = i - 1 {
 		definitionIndex := definitions[i]
-		program.Statements = append(
-			program.Statements[:definitionIndex],
-			program.Statements[definitionIndex+1:]...,
+		statements = append(
+			statements[:definitionIndex],
+			statements[definitionIndex+1:]...,
 		)
 	}
+	return statements
 }
 
 func isMacroDefinition(node ast.Statement) bool {
+	if export, ok := node.(*ast.ExportStatement); ok {
+		node = export.Statement
+	}
 	letStatement, ok := node.(*ast.LetStatement)
-	if !ok {
+	if !ok || letStatement.Name == nil {
 		return false
 	}
 
@@ -36,51 +75,369 @@ func isMacroDefinition(node ast.Statement) bool {
 }
 
 func addMacro(stmt ast.Statement, env *object.Environment) {
+	if export, ok := stmt.(*ast.ExportStatement); ok {
+		stmt = export.Statement
+	}
 	letStatement, _ := stmt.(*ast.LetStatement)
 	macroLiteral, _ := letStatement.Value.(*ast.MacroLiteral)
 	macro := &object.Macro{
 		Parameters: macroLiteral.Parameters,
+		Rest:       macroLiteral.Rest,
 		Body:       macroLiteral.Body,
 		Env:        env,
 	}
 	env.Set(letStatement.Name.Value, macro)
 }
 
-func ExpandMacros(program ast.Node, env *object.Environment) ast.Node {
-	return ast.Modify(program, func(node ast.Node) ast.Node {
-		callExpression, ok := node.(*ast.CallExpression)
-		if !ok {
-			return node
+// MacroExpander rewrites macro calls into the code they expand to. Macros
+// are scoped lexically: a definition inside a block is only visible to the
+// rest of that block. Expansions are expanded again until no macro calls
+// remain or MaxDepth nested expansions have happened.
+//
+// The expander also evaluates macroexpand(quote(expr)) and
+// macroexpand1(quote(expr)), replacing them with a quote of expr fully
+// expanded or expanded one step, respectively. Calls with any other
+// argument are left to the builtins of the same names.
+//
+// Import statements are resolved while expanding, so that macros exported
+// by the imported module can be used by the importing code. They are called
+// as module.name(...), like its other members, using the alias if the
+// import has one; without an alias they are also bound by their own names.
+type MacroExpander struct {
+	MaxDepth int
+	// Trace, if set, receives one line per expansion step.
+	Trace io.Writer
+	// File is the path of the code being expanded. Imports are resolved
+	// relative to its directory.
+	File string
+	// Modules loads imported modules. Share it with the environment the
+	// expanded code is evaluated in, through SetImporter, so that each
+	// module is loaded once.
+	Modules *ModuleLoader
+
+	errors []*MacroError
+}
+
+func NewMacroExpander() *MacroExpander {
+	return &MacroExpander{
+		MaxDepth: DefaultMaxExpansionDepth,
+		Modules:  NewModuleLoader(),
+	}
+}
+
+func (e *MacroExpander) Errors() []*MacroError {
+	return e.errors
+}
+
+// Expand expands all macro calls in node, defining macros found at its top
+// level in env. Calls that fail to expand are left in place and reported
+// through Errors.
+func (e *MacroExpander) Expand(node ast.Node, env *object.Environment) ast.Node {
+	if e.File != "" {
+		defer e.Modules.enter(e.File)()
+	}
+	return e.expand(node, env, 0)
+}
+
+func (e *MacroExpander) expand(node ast.Node, env *object.Environment, depth int) ast.Node {
+	switch node := node.(type) {
+	case *ast.Program:
+		node.Statements = defineMacros(node.Statements, env)
+	case *ast.BlockStatement:
+		env = object.NewEnclosedEnvironment(env)
+		node.Statements = defineMacros(node.Statements, env)
+	case *ast.ImportStatement:
+		e.importMacros(node, env)
+		return node
+	case *ast.PipeExpression:
+		// The piped value is the macro's first argument, so the call only
+		// makes sense as a whole.
+		call := node.Call()
+		if _, ok := isMacroCall(call, env); ok {
+			return e.expand(call, env, depth)
 		}
-		macro, ok := isMacroCall(callExpression, env)
-		if !ok {
+	case *ast.CallExpression:
+		if isMacroexpandCall(node) {
+			return e.expandMacroexpandCall(node, env, depth)
+		}
+		if isQuoteCall(node) {
 			return node
 		}
-		args := quoteArgs(callExpression)
-		evalEnv := extendMacroEnv(macro, args)
-		evaluated := Eval(macro.Body, evalEnv)
-		quote, ok := evaluated.(*object.Quote)
-		if !ok {
-			panic("we only support returning AST-nodes from macros")
+		if macro, ok := isMacroCall(node, env); ok {
+			return e.expandCall(node, macro, env, depth)
 		}
-		return quote.Node
+	}
+	ast.ModifyChildren(node, func(child ast.Node) ast.Node {
+		return e.expand(child, env, depth)
 	})
+	return node
 }
 
-func isMacroCall(node *ast.CallExpression, env *object.Environment) (*object.Macro, bool) {
-	identifier, ok := node.Function.(*ast.Identifier)
+func (e *MacroExpander) importMacros(node *ast.ImportStatement, env *object.Environment) {
+	module, err := e.Modules.importModule(node, e.File)
+	if err != nil {
+		e.errors = append(e.errors, &MacroError{
+			Kind:    MACRO_ERR_IMPORT,
+			Line:    node.Token.Line,
+			Column:  node.Token.Column,
+			Message: fmt.Sprintf("import %q: %s", node.Path.Value, err),
+		})
+		return
+	}
+	if node.Alias != nil {
+		env.Set(node.Alias.Value, module)
+		return
+	}
+	env.Set(module.Name, module)
+	for name, macro := range module.Macros {
+		env.Set(name, macro)
+	}
+}
+
+func (e *MacroExpander) expandCall(call *ast.CallExpression, macro object.Object,
+	env *object.Environment, depth int) ast.Node {
+	if depth >= e.MaxDepth {
+		e.errors = append(e.errors, newMacroError(call, MACRO_ERR_DEPTH,
+			"expansion depth exceeded %d", e.MaxDepth))
+		return call
+	}
+	expansion, ok := e.expandOnce(call, macro)
 	if !ok {
+		return call
+	}
+	return e.expand(expansion, env, depth+1)
+}
+
+func (e *MacroExpander) expandOnce(call *ast.CallExpression, macro object.Object) (ast.Node, bool) {
+	expansion, err := expandMacroCall(call, macro)
+	if err != nil {
+		e.errors = append(e.errors, err)
 		return nil, false
 	}
-	macro, ok := env.Get(identifier.Value)
+	if e.Trace != nil {
+		name, tok := macroCallee(call)
+		fmt.Fprintf(e.Trace, "%d:%d: expand %s: %s => %s\n",
+			tok.Line, tok.Column, name, call.String(), expansion.String())
+	}
+	return expansion, true
+}
+
+func (e *MacroExpander) expandMacroexpandCall(call *ast.CallExpression,
+	env *object.Environment, depth int) ast.Node {
+	identifier := call.Function.(*ast.Identifier)
+	quoted := call.Arguments[0].(*ast.CallExpression)
+	target := ast.Copy(quoted.Arguments[0])
+	if identifier.Value == "macroexpand1" {
+		target = e.expand1(target, env)
+	} else {
+		target = e.expand(target, env, depth)
+	}
+	quoted.Arguments[0], _ = target.(ast.Expression)
+	return quoted
+}
+
+// expand1 expands node once if it is a macro call, and returns it
+// unchanged otherwise.
+func (e *MacroExpander) expand1(node ast.Node, env *object.Environment) ast.Node {
+	call, ok := node.(*ast.CallExpression)
 	if !ok {
-		return nil, false
+		return node
 	}
-	macroObject, ok := macro.(*object.Macro)
+	macro, ok := isMacroCall(call, env)
 	if !ok {
+		return node
+	}
+	if expansion, ok := e.expandOnce(call, macro); ok {
+		return expansion
+	}
+	return node
+}
+
+// macroexpandBuiltin returns the macroexpand or macroexpand1 builtin for
+// code evaluated in env. It expands the macros of the environment recorded
+// with SetMacros or, in a macro body, those visible from env itself.
+func macroexpandBuiltin(name string, env *object.Environment) *object.Builtin {
+	macros := env.Macros()
+	if macros == nil {
+		macros = env
+	}
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			quote, ok := args[0].(*object.Quote)
+			if !ok {
+				return newError("argument to `%s` must be QUOTE, got %s", name, args[0].Type())
+			}
+			expander := NewMacroExpander()
+			target := ast.Copy(quote.Node)
+			if name == "macroexpand1" {
+				target = expander.expand1(target, macros)
+			} else {
+				target = expander.expand(target, object.NewEnclosedEnvironment(macros), 0)
+			}
+			if errs := expander.Errors(); len(errs) != 0 {
+				return newError("%s: %s", name, errs[0].Error())
+			}
+			return &object.Quote{Node: target}
+		},
+	}
+}
+
+// ExpandMacros expands program with a default MacroExpander.
+func ExpandMacros(program ast.Node, env *object.Environment) (ast.Node, []*MacroError) {
+	expander := NewMacroExpander()
+	expanded := expander.Expand(program, env)
+	return expanded, expander.Errors()
+}
+
+func newMacroError(call *ast.CallExpression, kind string, format string, a ...interface{}) *MacroError {
+	name, tok := macroCallee(call)
+	return &MacroError{
+		Kind:    kind,
+		Macro:   name,
+		Line:    tok.Line,
+		Column:  tok.Column,
+		Message: fmt.Sprintf(format, a...),
+	}
+}
+
+// macroCallee returns the name a macro call uses for its macro, either an
+// identifier or alias.name, and the token that name starts at.
+func macroCallee(call *ast.CallExpression) (string, token.Token) {
+	if member, ok := call.Function.(*ast.MemberExpression); ok {
+		alias := member.Object.(*ast.Identifier)
+		return alias.Value + "." + member.Property.Value, alias.Token
+	}
+	identifier := call.Function.(*ast.Identifier)
+	return identifier.Value, identifier.Token
+}
+
+func expandMacroCall(call *ast.CallExpression, macro object.Object) (ast.Node, *MacroError) {
+	if native, ok := macro.(*object.NativeMacro); ok {
+		return expandNativeMacroCall(call, native)
+	}
+	m := macro.(*object.Macro)
+	if m.Rest == nil && len(call.Arguments) != len(m.Parameters) {
+		return nil, newMacroError(call, MACRO_ERR_ARITY,
+			"wrong number of arguments. got=%d, want=%d",
+			len(call.Arguments), len(m.Parameters))
+	}
+	if m.Rest != nil && len(call.Arguments) < len(m.Parameters) {
+		return nil, newMacroError(call, MACRO_ERR_ARITY,
+			"wrong number of arguments. got=%d, want at least %d",
+			len(call.Arguments), len(m.Parameters))
+	}
+	args := quoteArgs(call)
+	evalEnv := extendMacroEnv(m, args)
+	evaluated := unwrapReturnValue(Eval(m.Body, evalEnv))
+	switch evaluated := evaluated.(type) {
+	case *object.Quote:
+		// As for native macros, a statement would be dropped when it is
+		// spliced in at the call.
+		if _, ok := evaluated.Node.(ast.Expression); !ok {
+			return nil, newMacroError(call, MACRO_ERR_RETURN_TYPE,
+				"macro must return a quoted expression, got %s", nodeKind(evaluated.Node))
+		}
+		return evaluated.Node, nil
+	case *object.Error:
+		return nil, newMacroError(call, MACRO_ERR_RUNTIME, "%s", evaluated.Message)
+	case nil:
+		return nil, newMacroError(call, MACRO_ERR_RETURN_TYPE,
+			"macro must return a Quote, got nothing")
+	default:
+		return nil, newMacroError(call, MACRO_ERR_RETURN_TYPE,
+			"macro must return a Quote, got %s", evaluated.Type())
+	}
+}
+
+// expandNativeMacroCall runs a Go macro on copies of the call's arguments.
+// Errors and panics from the Go function are reported like runtime errors
+// in an eslang macro body.
+func expandNativeMacroCall(call *ast.CallExpression, macro *object.NativeMacro) (node ast.Node, err *MacroError) {
+	defer func() {
+		if r := recover(); r != nil {
+			node = nil
+			err = newMacroError(call, MACRO_ERR_RUNTIME, "%v", r)
+		}
+	}()
+	args := []ast.Expression{}
+	for _, a := range call.Arguments {
+		args = append(args, ast.Copy(a).(ast.Expression))
+	}
+	expansion, fnErr := macro.Fn(args)
+	if fnErr != nil {
+		return nil, newMacroError(call, MACRO_ERR_RUNTIME, "%s", fnErr.Error())
+	}
+	if expansion == nil {
+		return nil, newMacroError(call, MACRO_ERR_RETURN_TYPE,
+			"native macro must return an ast.Node")
+	}
+	// The call stands in an expression position, where a statement would
+	// be dropped when it is spliced in.
+	if _, ok := expansion.(ast.Expression); !ok {
+		return nil, newMacroError(call, MACRO_ERR_RETURN_TYPE,
+			"native macro must return an ast.Expression, got %T", expansion)
+	}
+	return expansion, nil
+}
+
+// DefineNativeMacro installs a macro implemented in Go into env. The
+// expander calls fn with the unevaluated arguments of each call and splices
+// in the node it returns.
+func DefineNativeMacro(env *object.Environment, name string, fn object.NativeMacroFunction) {
+	env.Set(name, &object.NativeMacro{Name: name, Fn: fn})
+}
+
+func isMacroCall(node *ast.CallExpression, env *object.Environment) (object.Object, bool) {
+	var macro object.Object
+	switch function := node.Function.(type) {
+	case *ast.Identifier:
+		macro, _ = env.Get(function.Value)
+	case *ast.MemberExpression:
+		alias, ok := function.Object.(*ast.Identifier)
+		if !ok {
+			return nil, false
+		}
+		module, _ := env.Get(alias.Value)
+		if module, ok := module.(*object.Module); ok {
+			macro = module.Macros[function.Property.Value]
+		}
+	}
+	if !isMacro(macro) {
 		return nil, false
 	}
-	return macroObject, true
+	return macro, true
+}
+
+func isMacro(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Macro, *object.NativeMacro:
+		return true
+	default:
+		return false
+	}
+}
+
+// isMacroexpandCall reports whether node is macroexpand or macroexpand1
+// called with a single quote(expr), which the expander evaluates itself.
+func isMacroexpandCall(node *ast.CallExpression) bool {
+	identifier, ok := node.Function.(*ast.Identifier)
+	if !ok || !isMacroexpandName(identifier.Value) || len(node.Arguments) != 1 {
+		return false
+	}
+	quoted, ok := node.Arguments[0].(*ast.CallExpression)
+	return ok && isQuoteCall(quoted) && len(quoted.Arguments) == 1
+}
+
+func isMacroexpandName(name string) bool {
+	return name == "macroexpand" || name == "macroexpand1"
+}
+
+func isQuoteCall(node *ast.CallExpression) bool {
+	identifier, ok := node.Function.(*ast.Identifier)
+	return ok && identifier.Value == "quote"
 }
 
 func quoteArgs(exp *ast.CallExpression) []*object.Quote {
@@ -96,5 +453,12 @@ func extendMacroEnv(m *object.Macro, args []*object.Quote) *object.Environment {
 	for paramIdx, param := range m.Parameters {
 		extended.Set(param.Value, args[paramIdx])
 	}
+	if m.Rest != nil {
+		rest := []object.Object{}
+		for _, arg := range args[len(m.Parameters):] {
+			rest = append(rest, arg)
+		}
+		extended.Set(m.Rest.Value, &object.Array{Elements: rest})
+	}
 	return extended
 }
@@ -1,6 +1,8 @@
 package eval
 
 import (
+	"bytes"
+	"fmt"
 	"learn-interpreter/ast"
 	"learn-interpreter/lexer"
 	"learn-interpreter/object"
@@ -91,15 +93,337 @@ func TestExpandMacros(t *testing.T) {
  `,
 			`if (!(10 > 5)) { puts("not greater") } else { puts("greater") }`,
 		},
+		{
+			`
+ let reverse = macro(a, b) { quote(unquote(b) - unquote(a)); };
+ 1 |> reverse(2);
+ `,
+			`2 - 1`,
+		},
+		{
+			`
+ let twice = macro(x) { quote(unquote(x) * 2); };
+ 3 |> twice;
+ 1 |> f(twice(2)) |> g;
+ `,
+			`3 * 2; 1 |> f(2 * 2) |> g`,
+		},
+	}
+	for _, tt := range tests {
+		expected := testParseProgram(tt.expected)
+		program := testParseProgram(tt.input)
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		expanded, errs := ExpandMacros(program, env)
+		if len(errs) != 0 {
+			t.Fatalf("unexpected macro errors: %v", errs)
+		}
+		if expanded.String() != expected.String() {
+			t.Errorf("not equal. want=%q, got=%q", expected.String(), expanded.String())
+		}
+	}
+}
+
+func TestExpandMacrosErrors(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedKind string
+		expectedMsg  string
+	}{
+		{
+			`let m = macro(a, b) { quote(unquote(a) + unquote(b)); }; m(1);`,
+			MACRO_ERR_ARITY,
+			"wrong number of arguments. got=1, want=2",
+		},
+		{
+			`let m = macro() { 1 + 1; }; m();`,
+			MACRO_ERR_RETURN_TYPE,
+			"macro must return a Quote, got Integer",
+		},
+		{
+			`let m = macro() { }; m();`,
+			MACRO_ERR_RETURN_TYPE,
+			"macro must return a Quote, got nothing",
+		},
+		{
+			`let m = macro(x) { node_children(x)[1] }; puts(m(if (true) { 1 }));`,
+			MACRO_ERR_RETURN_TYPE,
+			"macro must return a quoted expression, got BlockStatement",
+		},
+		{
+			`let m = macro(x) { return quote(unquote(x)); }; m(1); m(true + 1);`,
+			"",
+			"",
+		},
+		{
+			`let m = macro() { foo; }; m();`,
+			MACRO_ERR_RUNTIME,
+			"identifier not found: foo",
+		},
+	}
+	for _, tt := range tests {
+		program := testParseProgram(tt.input)
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		_, errs := ExpandMacros(program, env)
+		if tt.expectedKind == "" {
+			if len(errs) != 0 {
+				t.Errorf("unexpected macro errors: %v", errs)
+			}
+			continue
+		}
+		if len(errs) != 1 {
+			t.Fatalf("wrong number of macro errors. got=%d, want=1", len(errs))
+		}
+		if errs[0].Macro != "m" {
+			t.Errorf("wrong macro name. got=%q", errs[0].Macro)
+		}
+		if errs[0].Kind != tt.expectedKind {
+			t.Errorf("wrong error kind. got=%q, want=%q", errs[0].Kind, tt.expectedKind)
+		}
+		if errs[0].Message != tt.expectedMsg {
+			t.Errorf("wrong error message. got=%q, want=%q", errs[0].Message, tt.expectedMsg)
+		}
+	}
+}
+
+func TestExpandNestedAndRecursiveMacros(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`
+ let f = fn() {
+ let double = macro(x) { quote(unquote(x) * 2); };
+ double(3);
+ };
+ `,
+			`let f = fn() { (3 * 2) };`,
+		},
+		{
+			`
+ let inc = macro(x) { quote(unquote(x) + 1); };
+ let twice = macro(x) { quote(inc(inc(unquote(x)))); };
+ twice(1);
+ `,
+			`((1 + 1) + 1)`,
+		},
+		{
+			`
+ let id = macro(x) { quote(unquote(x)); };
+ puts(id(1), id(id(2)));
+ `,
+			`puts(1, 2)`,
+		},
+		{
+			`
+ if (true) { let m = macro() { quote(1); }; m(); };
+ m();
+ `,
+			`if (true) { 1 }; m();`,
+		},
 	}
 	for _, tt := range tests {
 		expected := testParseProgram(tt.expected)
 		program := testParseProgram(tt.input)
 		env := object.NewEnvironment()
 		DefineMacros(program, env)
-		expanded := ExpandMacros(program, env)
+		expanded, errs := ExpandMacros(program, env)
+		if len(errs) != 0 {
+			t.Fatalf("unexpected macro errors: %v", errs)
+		}
 		if expanded.String() != expected.String() {
 			t.Errorf("not equal. want=%q, got=%q", expected.String(), expanded.String())
 		}
 	}
 }
+
+func TestExpandMacrosMaxDepth(t *testing.T) {
+	input := `let loop = macro(x) { quote(loop(unquote(x))); }; loop(1);`
+	program := testParseProgram(input)
+	env := object.NewEnvironment()
+	expander := NewMacroExpander()
+	expander.MaxDepth = 5
+	expander.Expand(program, env)
+	errs := expander.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("wrong number of macro errors. got=%d, want=1", len(errs))
+	}
+	if errs[0].Kind != MACRO_ERR_DEPTH {
+		t.Errorf("wrong error kind. got=%q", errs[0].Kind)
+	}
+	if errs[0].Message != "expansion depth exceeded 5" {
+		t.Errorf("wrong error message. got=%q", errs[0].Message)
+	}
+}
+
+func TestVariadicMacros(t *testing.T) {
+	input := `
+ let cond = macro(test, value, ...clauses) {
+ if (len(clauses) == 0) {
+ quote(if (unquote(test)) { unquote(value) })
+ } else {
+ quote(if (unquote(test)) { unquote(value) } else { cond(unquote_splice(clauses)) })
+ }
+ };
+ let do = macro(...body) { quote(fn() { unquote_splice(body) }()) };
+ let classify = fn(x) { cond(x < 0, "negative", x == 0, "zero", true, "positive") };
+ [do(1, classify(-5)), classify(0), do(classify(7))];
+ `
+	program := testParseProgram(input)
+	env := object.NewEnvironment()
+	expanded, errs := ExpandMacros(program, env)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected macro errors: %v", errs)
+	}
+	evaluated := Eval(expanded, object.NewEnvironment())
+	array, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+	if array.Inspect() != "[negative, zero, positive]" {
+		t.Errorf("wrong result. got=%q", array.Inspect())
+	}
+}
+
+func TestMacroexpand(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let inc = macro(x) { quote(unquote(x) + 1); };
+ macroexpand(quote(inc(inc(1))));`,
+			`((1 + 1) + 1)`,
+		},
+		{
+			`let inc = macro(x) { quote(unquote(x) + 1); };
+ macroexpand1(quote(inc(inc(1))));`,
+			`(inc(1) + 1)`,
+		},
+		{
+			`macroexpand1(quote(puts(1)));`,
+			`puts(1)`,
+		},
+	}
+	for _, tt := range tests {
+		program := testParseProgram(tt.input)
+		env := object.NewEnvironment()
+		expanded, errs := ExpandMacros(program, env)
+		if len(errs) != 0 {
+			t.Fatalf("unexpected macro errors: %v", errs)
+		}
+		evaluated := Eval(expanded, object.NewEnvironment())
+		quote, ok := evaluated.(*object.Quote)
+		if !ok {
+			t.Fatalf("expected *object.Quote. got=%T (%+v)", evaluated, evaluated)
+		}
+		if quote.Node.String() != tt.expected {
+			t.Errorf("not equal. got=%q, want=%q", quote.Node.String(), tt.expected)
+		}
+	}
+}
+
+func TestMacroexpandBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let q = quote(inc(inc(1))); [macroexpand(q), macroexpand1(q)]`,
+			"[QUOTE(((1 + 1) + 1)), QUOTE((inc(1) + 1))]"},
+		{`let f = fn(q) { macroexpand(q) }; f(quote(inc(2)))`, "QUOTE((2 + 1))"},
+		{`macroexpand1(quote(puts(1)))`, "QUOTE(puts(1))"},
+		{`let kind = macro(e) { quote(unquote(node_kind(macroexpand(e)))) }; kind(inc(1))`, "InfixExpression"},
+		{`let pair = macro(e) { quote(unquote(macroexpand1(e))) }; pair(inc(inc(1)))`, "3"},
+		{`macroexpand(1)`, "ERROR: argument to `macroexpand` must be QUOTE, got Integer"},
+		{`macroexpand1()`, "ERROR: wrong number of arguments. got=0, want=1"},
+		{`let q = quote(inc()); macroexpand(q)`, "ERROR: macroexpand: 1:62: macro inc: wrong number of arguments. got=0, want=1"},
+	}
+	for _, tt := range tests {
+		program := testParseProgram(`let inc = macro(x) { quote(unquote(x) + 1); }; ` + tt.input)
+		macroEnv := object.NewEnvironment()
+		expanded, errs := ExpandMacros(program, macroEnv)
+		if len(errs) != 0 {
+			t.Fatalf("unexpected macro errors: %v", errs)
+		}
+		env := object.NewEnvironment()
+		env.SetMacros(macroEnv)
+		evaluated := Eval(expanded, env)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestMacroExpansionTrace(t *testing.T) {
+	input := `let inc = macro(x) { quote(unquote(x) + 1); };
+inc(inc(1));`
+	program := testParseProgram(input)
+	var trace bytes.Buffer
+	expander := NewMacroExpander()
+	expander.Trace = &trace
+	expander.Expand(program, object.NewEnvironment())
+	expected := "2:1: expand inc: inc(inc(1)) => (inc(1) + 1)\n" +
+		"2:5: expand inc: inc(1) => (1 + 1)\n"
+	if trace.String() != expected {
+		t.Errorf("wrong trace. got=%q, want=%q", trace.String(), expected)
+	}
+}
+
+func TestNativeMacros(t *testing.T) {
+	env := object.NewEnvironment()
+	DefineNativeMacro(env, "swap", func(args []ast.Expression) (ast.Node, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("want 1 argument, got %d", len(args))
+		}
+		infix, ok := args[0].(*ast.InfixExpression)
+		if !ok {
+			return nil, fmt.Errorf("want an infix expression, got %s", args[0].String())
+		}
+		infix.Left, infix.Right = infix.Right, infix.Left
+		return infix, nil
+	})
+	DefineNativeMacro(env, "nothing", func(args []ast.Expression) (ast.Node, error) {
+		return nil, nil
+	})
+	DefineNativeMacro(env, "statement", func(args []ast.Expression) (ast.Node, error) {
+		return &ast.ReturnStatement{ReturnValue: args[0]}, nil
+	})
+	DefineNativeMacro(env, "boom", func(args []ast.Expression) (ast.Node, error) {
+		return args[5], nil
+	})
+
+	program := testParseProgram(`let inc = macro(x) { quote(unquote(x) + 1) }; swap(10 - inc(2));`)
+	expanded, errs := ExpandMacros(program, env)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected macro errors: %v", errs)
+	}
+	if expanded.String() != "((2 + 1) - 10)" {
+		t.Errorf("wrong expansion. got=%q", expanded.String())
+	}
+
+	tests := []struct {
+		input        string
+		expectedKind string
+		expectedMsg  string
+	}{
+		{`swap(1)`, MACRO_ERR_RUNTIME, "want an infix expression, got 1"},
+		{`nothing()`, MACRO_ERR_RETURN_TYPE, "native macro must return an ast.Node"},
+		{`statement(1)`, MACRO_ERR_RETURN_TYPE, "native macro must return an ast.Expression, got *ast.ReturnStatement"},
+		{`let x = statement(1);`, MACRO_ERR_RETURN_TYPE, "native macro must return an ast.Expression, got *ast.ReturnStatement"},
+		{`boom()`, MACRO_ERR_RUNTIME, "runtime error: index out of range [5] with length 0"},
+	}
+	for _, tt := range tests {
+		_, errs := ExpandMacros(testParseProgram(tt.input), env)
+		if len(errs) != 1 {
+			t.Fatalf("wrong number of macro errors. got=%d, want=1", len(errs))
+		}
+		if errs[0].Kind != tt.expectedKind {
+			t.Errorf("wrong error kind. got=%q, want=%q", errs[0].Kind, tt.expectedKind)
+		}
+		if errs[0].Message != tt.expectedMsg {
+			t.Errorf("wrong error message. got=%q, want=%q", errs[0].Message, tt.expectedMsg)
+		}
+	}
+}
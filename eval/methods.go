@@ -0,0 +1,254 @@
+package eval
+
+import (
+	"learn-interpreter/ast"
+	"learn-interpreter/object"
+	"strings"
+)
+
+// method is a built-in method implemented in Go; receiver is the value the
+// method was called on.
+type method func(receiver object.Object, args ...object.Object) object.Object
+
+// methods holds the built-in methods of each type. It is filled in by init
+// because map, filter and reduce need applyFunction, which depends on
+// builtins through evalIdentifier.
+var methods map[object.ObjectType]map[string]method
+
+func init() {
+	methods = map[object.ObjectType]map[string]method{
+		object.OBJ_TYPE_STRING: {
+			"len": func(receiver object.Object, args ...object.Object) object.Object {
+				return builtins["len"].Fn(receiver)
+			},
+			"upper": stringMethod(strings.ToUpper),
+			"lower": stringMethod(strings.ToLower),
+			"trim":  stringMethod(strings.TrimSpace),
+			"split": func(receiver object.Object, args ...object.Object) object.Object {
+				sep, err := stringArgument("split", args)
+				if err != nil {
+					return err
+				}
+				elements := []object.Object{}
+				for _, part := range strings.Split(receiver.(*object.String).Value, sep) {
+					elements = append(elements, &object.String{Value: part})
+				}
+				return &object.Array{Elements: elements}
+			},
+			"contains":    stringPredicate("contains", strings.Contains),
+			"starts_with": stringPredicate("starts_with", strings.HasPrefix),
+			"ends_with":   stringPredicate("ends_with", strings.HasSuffix),
+		},
+		object.OBJ_TYPE_ARRAY: {
+			"len": func(receiver object.Object, args ...object.Object) object.Object {
+				return builtins["len"].Fn(receiver)
+			},
+			"first": func(receiver object.Object, args ...object.Object) object.Object {
+				return builtins["first"].Fn(receiver)
+			},
+			"last": func(receiver object.Object, args ...object.Object) object.Object {
+				return builtins["last"].Fn(receiver)
+			},
+			"rest": func(receiver object.Object, args ...object.Object) object.Object {
+				return builtins["rest"].Fn(receiver)
+			},
+			"push": func(receiver object.Object, args ...object.Object) object.Object {
+				return builtins["push"].Fn(append([]object.Object{receiver}, args...)...)
+			},
+			"map": func(receiver object.Object, args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1", len(args))
+				}
+				result := []object.Object{}
+				for _, el := range receiver.(*object.Array).Elements {
+					mapped := applyFunction(args[0], []object.Object{el})
+					if isError(mapped) {
+						return mapped
+					}
+					result = append(result, mapped)
+				}
+				return &object.Array{Elements: result}
+			},
+			"filter": func(receiver object.Object, args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1", len(args))
+				}
+				result := []object.Object{}
+				for _, el := range receiver.(*object.Array).Elements {
+					keep := applyFunction(args[0], []object.Object{el})
+					if isError(keep) {
+						return keep
+					}
+					if isTruthy(keep) {
+						result = append(result, el)
+					}
+				}
+				return &object.Array{Elements: result}
+			},
+			"reduce": func(receiver object.Object, args ...object.Object) object.Object {
+				if len(args) != 2 {
+					return newError("wrong number of arguments. got=%d, want=2", len(args))
+				}
+				accumulator := args[1]
+				for _, el := range receiver.(*object.Array).Elements {
+					accumulator = applyFunction(args[0], []object.Object{accumulator, el})
+					if isError(accumulator) {
+						return accumulator
+					}
+				}
+				return accumulator
+			},
+			"join": func(receiver object.Object, args ...object.Object) object.Object {
+				sep, err := stringArgument("join", args)
+				if err != nil {
+					return err
+				}
+				parts := []string{}
+				for _, el := range receiver.(*object.Array).Elements {
+					if str, ok := el.(*object.String); ok {
+						parts = append(parts, str.Value)
+					} else {
+						parts = append(parts, el.Inspect())
+					}
+				}
+				return &object.String{Value: strings.Join(parts, sep)}
+			},
+		},
+		object.OBJ_TYPE_HASH: {
+			"len": func(receiver object.Object, args ...object.Object) object.Object {
+				return &object.Integer{Value: int64(len(receiver.(*object.Hash).Pairs))}
+			},
+			"keys": func(receiver object.Object, args ...object.Object) object.Object {
+				keys := []object.Object{}
+				for _, pair := range receiver.(*object.Hash).Pairs {
+					keys = append(keys, pair.Key)
+				}
+				return &object.Array{Elements: keys}
+			},
+			"values": func(receiver object.Object, args ...object.Object) object.Object {
+				values := []object.Object{}
+				for _, pair := range receiver.(*object.Hash).Pairs {
+					values = append(values, pair.Value)
+				}
+				return &object.Array{Elements: values}
+			},
+			"has": func(receiver object.Object, args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return newError("wrong number of arguments. got=%d, want=1", len(args))
+				}
+				key, ok := args[0].(object.Hashable)
+				if !ok {
+					return newError("unusable as hash key: %s", args[0].Type())
+				}
+				_, ok = receiver.(*object.Hash).Pairs[key.HashKey()]
+				return nativeBoolToBooleanObject(ok)
+			},
+		},
+	}
+}
+
+func stringMethod(fn func(string) string) method {
+	return func(receiver object.Object, args ...object.Object) object.Object {
+		if len(args) != 0 {
+			return newError("wrong number of arguments. got=%d, want=0", len(args))
+		}
+		return &object.String{Value: fn(receiver.(*object.String).Value)}
+	}
+}
+
+func stringPredicate(name string, fn func(string, string) bool) method {
+	return func(receiver object.Object, args ...object.Object) object.Object {
+		arg, err := stringArgument(name, args)
+		if err != nil {
+			return err
+		}
+		return nativeBoolToBooleanObject(fn(receiver.(*object.String).Value, arg))
+	}
+}
+
+func stringArgument(name string, args []object.Object) (string, *object.Error) {
+	if len(args) != 1 {
+		return "", newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	str, ok := args[0].(*object.String)
+	if !ok {
+		return "", newError("argument to `%s` must be STRING, got %s", name, args[0].Type())
+	}
+	return str.Value, nil
+}
+
+func evalMemberExpression(node *ast.MemberExpression, env *object.Environment) object.Object {
+	obj := Eval(node.Object, env)
+	if isError(obj) {
+		return obj
+	}
+	name := &object.String{Value: node.Property.Value}
+	switch obj.Type() {
+	case object.OBJ_TYPE_HASH:
+		return evalHashIndexExpression(obj, name)
+	case object.OBJ_TYPE_MODULE:
+		return evalModuleIndexExpression(obj, name)
+	case object.OBJ_TYPE_STRUCT:
+		return evalStructField(obj.(*object.Struct), name.Value)
+	default:
+		return newError("%s has no field %s", obj.Type(), node.Property.Value)
+	}
+}
+
+// evalMethodCall evaluates receiver.name(args). Functions stored in a hash
+// are called with the hash bound to self; otherwise the receiver's built-in
+// methods are searched.
+func evalMethodCall(node *ast.MemberExpression, argNodes []ast.Expression, env *object.Environment) object.Object {
+	receiver := Eval(node.Object, env)
+	if isError(receiver) {
+		return receiver
+	}
+	args, named, err := evalArguments(argNodes, env)
+	if err != nil {
+		return err
+	}
+	name := node.Property.Value
+
+	switch receiver := receiver.(type) {
+	case *object.Hash:
+		key := &object.String{Value: name}
+		if pair, ok := receiver.Pairs[key.HashKey()]; ok {
+			return applyMethod(pair.Value, receiver, args, named)
+		}
+	case *object.Module:
+		fn := evalModuleIndexExpression(receiver, &object.String{Value: name})
+		if isError(fn) {
+			return fn
+		}
+		return callFunction(fn, args, named)
+	case *object.Struct:
+		if field, ok := receiver.Fields[name]; ok {
+			return applyMethod(field, receiver, args, named)
+		}
+	}
+	if m, ok := methods[receiver.Type()][name]; ok {
+		if len(named) > 0 {
+			return newError("method %s does not accept named arguments", name)
+		}
+		return m(receiver, args...)
+	}
+	return newError("undefined method %s for %s", name, receiver.Type())
+}
+
+// applyMethod calls fn with self bound to receiver. self is bound outside
+// the parameters, so a parameter named self shadows it.
+func applyMethod(fn object.Object, receiver object.Object, args []object.Object, named map[string]object.Object) object.Object {
+	function, ok := fn.(*object.Function)
+	if !ok {
+		return callFunction(fn, args, named)
+	}
+	method := *function
+	method.Env = object.NewEnclosedEnvironment(function.Env)
+	method.Env.Set("self", receiver)
+	extendedEnv, err := extendFunctionEnv(&method, args, named)
+	if err != nil {
+		return err
+	}
+	evaluated := Eval(function.Body, extendedEnv)
+	return unwrapReturnValue(evaluated)
+}
@@ -0,0 +1,67 @@
+package eval
+
+import (
+	"testing"
+)
+
+func TestMemberAccess(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let h = {"name": "eslang", "size": 3}; h.size`, 3},
+		{`{"a": {"b": 2}}.a.b`, 2},
+		{`{"a": 1}.missing`, nil},
+		{`let p = {"x": 1}; p.x + p["x"]`, 2},
+		{`5.x`, "ERROR: Integer has no field x"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), expected)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestMethodCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"Hello".upper()`, "HELLO"},
+		{`"Hello".lower().len()`, "5"},
+		{`"  pad ".trim()`, "pad"},
+		{`"a,b,c".split(",")`, "[a, b, c]"},
+		{`"eslang".starts_with("es")`, "true"},
+		{`"eslang".contains("x")`, "false"},
+		{`[1, 2, 3].map(fn(x) { x * 2 })`, "[2, 4, 6]"},
+		{`[1, 2, 3, 4].filter(fn(x) { x > 2 })`, "[3, 4]"},
+		{`[1, 2, 3].reduce(fn(acc, x) { acc + x }, 10)`, "16"},
+		{`[1, "a", true].join("-")`, "1-a-true"},
+		{`[1, 2].push(3).len()`, "3"},
+		{`[1, 2, 3].rest().first()`, "2"},
+		{`{"a": 1}.has("a")`, "true"},
+		{`{"a": 1, "b": 2}.len()`, "2"},
+		{`{"a": 1}.keys()`, "[a]"},
+		{`let counter = {"n": 41, "next": fn() { self.n + 1 }}; counter.next()`, "42"},
+		{`{"f": fn(self) { self }}.f(3)`, "3"},
+		{`{"n": 1, "f": fn(x) { fn() { self.n + x } }}.f(2)()`, "3"},
+		{`let h = {"add": fn(a, b) { a + b }}; h.add(1, 2)`, "3"},
+		{`"s".nope()`, "ERROR: undefined method nope for String"},
+		{`[1].map(fn(x) { x + true })`, "ERROR: type mismatch: Integer + Boolean"},
+		{`"s".split(1)`, "ERROR: argument to `split` must be STRING, got Integer"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package eval
+
+import (
+	"fmt"
+	"learn-interpreter/ast"
+	"learn-interpreter/lexer"
+	"learn-interpreter/object"
+	"learn-interpreter/parser"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ModuleExtension is appended to import paths that have no extension.
+const ModuleExtension = ".es"
+
+// ModuleLoader resolves import paths, evaluates each module once into its
+// own environment and caches the result.
+type ModuleLoader struct {
+	// SearchPath lists directories tried after the importing file's own
+	// directory.
+	SearchPath []string
+
+	modules map[string]*object.Module
+	loading []string
+}
+
+func NewModuleLoader() *ModuleLoader {
+	return &ModuleLoader{modules: make(map[string]*object.Module)}
+}
+
+// Resolve finds the file imported as path from the file importer, which may
+// be empty for code that does not come from a file. It returns an absolute
+// path.
+func (ml *ModuleLoader) Resolve(path string, importer string) (string, error) {
+	if filepath.Ext(path) == "" {
+		path += ModuleExtension
+	}
+	candidates := []string{path}
+	if !filepath.IsAbs(path) {
+		dir := "."
+		if importer != "" {
+			dir = filepath.Dir(importer)
+		}
+		candidates = []string{filepath.Join(dir, path)}
+		for _, searchDir := range ml.SearchPath {
+			candidates = append(candidates, filepath.Join(searchDir, path))
+		}
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return filepath.Abs(candidate)
+		}
+	}
+	return "", fmt.Errorf("cannot find module %q", path)
+}
+
+// Load returns the module stored at the absolute path, parsing, expanding
+// and evaluating it the first time it is requested.
+func (ml *ModuleLoader) Load(path string) (*object.Module, error) {
+	if module, ok := ml.modules[path]; ok {
+		return module, nil
+	}
+	for i, loading := range ml.loading {
+		if loading == path {
+			cycle := []string{}
+			for _, p := range append(ml.loading[i:], path) {
+				cycle = append(cycle, filepath.Base(p))
+			}
+			return nil, fmt.Errorf("import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	input, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := parser.New(lexer.New(string(input)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, fmt.Errorf("%s: %s", path, strings.Join(p.Errors(), "; "))
+	}
+	exported := exportedNames(program)
+
+	macroEnv := object.NewEnvironment()
+	expander := NewMacroExpander()
+	expander.File = path
+	expander.Modules = ml
+	expanded := expander.Expand(program, macroEnv)
+	if len(expander.Errors()) != 0 {
+		messages := []string{}
+		for _, err := range expander.Errors() {
+			messages = append(messages, err.Error())
+		}
+		return nil, fmt.Errorf("%s: %s", path, strings.Join(messages, "; "))
+	}
+	env := object.NewEnvironment()
+	env.SetImporter(path, ml)
+	env.SetMacros(macroEnv)
+	if errObj, ok := Eval(expanded, env).(*object.Error); ok {
+		return nil, fmt.Errorf("%s: %s", path, errObj.Message)
+	}
+
+	module := &object.Module{
+		Name:    strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Path:    path,
+		Members: make(map[string]object.Object),
+		Macros:  make(map[string]object.Object),
+	}
+	for _, stmt := range program.Statements {
+		if export, ok := stmt.(*ast.ExportStatement); ok {
+			stmt = export.Statement
+		}
+		if decl, ok := stmt.(*ast.StructStatement); ok {
+			value, _ := env.Get(decl.Name.Value)
+			if structType, ok := value.(*object.StructType); ok {
+				structType.Module = module.Name
+			}
+		}
+	}
+	for _, name := range exported {
+		if macro, ok := macroEnv.Get(name); ok && isMacro(macro) {
+			module.Macros[name] = macro
+		} else if value, ok := env.Get(name); ok {
+			module.Members[name] = value
+		}
+	}
+	ml.modules[path] = module
+	return module, nil
+}
+
+// enter marks the file at path as being expanded until the returned function
+// is called, so that importing it again is reported as a cycle.
+func (ml *ModuleLoader) enter(path string) func() {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	ml.loading = append(ml.loading, path)
+	return func() { ml.loading = ml.loading[:len(ml.loading)-1] }
+}
+
+func exportedNames(program *ast.Program) []string {
+	names := []string{}
+	for _, stmt := range program.Statements {
+		export, ok := stmt.(*ast.ExportStatement)
+		if !ok {
+			continue
+		}
+		switch stmt := export.Statement.(type) {
+		case *ast.LetStatement:
+			if stmt.Pattern != nil {
+				names = append(names, patternNames(stmt.Pattern)...)
+			} else {
+				names = append(names, stmt.Name.Value)
+			}
+		case *ast.StructStatement:
+			names = append(names, stmt.Name.Value)
+		}
+	}
+	return names
+}
+
+// Import resolves path from the file importer and loads the module stored
+// there.
+func (ml *ModuleLoader) Import(path string, importer string) (*object.Module, error) {
+	resolved, err := ml.Resolve(path, importer)
+	if err != nil {
+		return nil, err
+	}
+	return ml.Load(resolved)
+}
+
+// importModule loads the module imported by node from the file importer and
+// records the resolved path on node.
+func (ml *ModuleLoader) importModule(node *ast.ImportStatement, importer string) (*object.Module, error) {
+	if node.Resolved == "" {
+		resolved, err := ml.Resolve(node.Path.Value, importer)
+		if err != nil {
+			return nil, err
+		}
+		node.Resolved = resolved
+	}
+	return ml.Load(node.Resolved)
+}
+
+func evalImportStatement(node *ast.ImportStatement, env *object.Environment) object.Object {
+	file, importer := env.Importer()
+	if importer == nil {
+		return newError("import %q: no module loader", node.Path.Value)
+	}
+	path := node.Path.Value
+	if node.Resolved != "" {
+		path = node.Resolved
+	}
+	module, err := importer.Import(path, file)
+	if err != nil {
+		return newError("import %q: %s", node.Path.Value, err)
+	}
+	name := module.Name
+	if node.Alias != nil {
+		name = node.Alias.Value
+	}
+	if err := checkDeclaration(env, []string{name}, false); err != nil {
+		return err
+	}
+	env.Set(name, module)
+	return nil
+}
+
+func evalModuleIndexExpression(module, index object.Object) object.Object {
+	moduleObject := module.(*object.Module)
+	name, ok := index.(*object.String)
+	if !ok {
+		return newError("module member name must be STRING, got %s", index.Type())
+	}
+	member, ok := moduleObject.Members[name.Value]
+	if !ok {
+		return newError("module %s has no exported member %s", moduleObject.Name, name.Value)
+	}
+	return member
+}
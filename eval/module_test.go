@@ -0,0 +1,236 @@
+package eval
+
+import (
+	"learn-interpreter/object"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeModules(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func testEvalFile(t *testing.T, path string) (object.Object, []*MacroError) {
+	return testEvalFileWith(t, path, NewModuleLoader())
+}
+
+func testEvalFileWith(t *testing.T, path string, modules *ModuleLoader) (object.Object, []*MacroError) {
+	input, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	program := testParseProgram(string(input))
+	expander := NewMacroExpander()
+	expander.File = path
+	expander.Modules = modules
+	expanded := expander.Expand(program, object.NewEnvironment())
+	if len(expander.Errors()) != 0 {
+		return nil, expander.Errors()
+	}
+	env := object.NewEnvironment()
+	env.SetImporter(path, modules)
+	return Eval(expanded, env), nil
+}
+
+func TestImport(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"main.es": `
+import "lib/math.es";
+import "lib/strings" as s;
+[math["double"](4), math.twice(1), s.greet("bob")];
+`,
+		"lib/math.es": `
+let helper = fn(x) { x * 2 };
+export let double = fn(x) { helper(x) };
+export let twice = fn(x) { double(double(x)) };
+`,
+		"lib/strings.es": `
+import "math.es";
+export let greet = fn(name) { "hi " + name };
+`,
+	})
+	evaluated, errs := testEvalFile(t, filepath.Join(dir, "main.es"))
+	if len(errs) != 0 {
+		t.Fatalf("unexpected macro errors: %v", errs)
+	}
+	if evaluated.Inspect() != "[8, 4, hi bob]" {
+		t.Errorf("wrong result. got=%q", evaluated.Inspect())
+	}
+}
+
+func TestImportHidesUnexportedBindings(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"main.es": `import "lib.es"; lib["helper"];`,
+		"lib.es":  `let helper = 1; export let visible = 2;`,
+	})
+	evaluated, errs := testEvalFile(t, filepath.Join(dir, "main.es"))
+	if len(errs) != 0 {
+		t.Fatalf("unexpected macro errors: %v", errs)
+	}
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "module lib has no exported member helper" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestImportEvaluatesModuleOnce(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"main.es":    `import "a.es"; import "b.es"; [a["counter"], b["counter"]];`,
+		"a.es":       `import "counter.es"; export let counter = counter["value"];`,
+		"b.es":       `import "counter.es"; export let counter = counter["value"];`,
+		"counter.es": `export let value = fn() { 1 };`,
+	})
+	evaluated, errs := testEvalFile(t, filepath.Join(dir, "main.es"))
+	if len(errs) != 0 {
+		t.Fatalf("unexpected macro errors: %v", errs)
+	}
+	counters, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+	if counters.Elements[0] != counters.Elements[1] {
+		t.Errorf("module was evaluated more than once")
+	}
+}
+
+func TestImportMacros(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"main.es": `import "macros.es"; [unless(false, 1, 2), macros.unless(true, 1, 2)];`,
+		"macros.es": `
+export let unless = macro(cond, cons, alt) {
+ quote(if (!(unquote(cond))) { unquote(cons) } else { unquote(alt) })
+};
+`,
+	})
+	evaluated, errs := testEvalFile(t, filepath.Join(dir, "main.es"))
+	if len(errs) != 0 {
+		t.Fatalf("unexpected macro errors: %v", errs)
+	}
+	if evaluated.Inspect() != "[1, 2]" {
+		t.Errorf("wrong result. got=%q, want=%q", evaluated.Inspect(), "[1, 2]")
+	}
+}
+
+func TestImportMacrosWithAlias(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"main.es":  `import "macros.es" as m; m.unless(false, 1, 2);`,
+		"other.es": `import "macros.es" as m; unless(false, 1, 2);`,
+		"macros.es": `
+export let unless = macro(cond, cons, alt) {
+	quote(if (!(unquote(cond))) { unquote(cons) } else { unquote(alt) });
+};`,
+	})
+	evaluated, errs := testEvalFile(t, filepath.Join(dir, "main.es"))
+	if len(errs) != 0 {
+		t.Fatalf("unexpected macro errors: %v", errs)
+	}
+	testIntegerObject(t, evaluated, 1)
+
+	// An aliased import keeps the macros out of the importer's own scope.
+	evaluated, errs = testEvalFile(t, filepath.Join(dir, "other.es"))
+	if len(errs) != 0 {
+		t.Fatalf("unexpected macro errors: %v", errs)
+	}
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "identifier not found: unless" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+// Imports the expander has not resolved are resolved by the evaluator from
+// the directory of the importing file as well.
+func TestEvalImportRelativeToFile(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"main.es":     `import "lib/math.es"; math["double"](21);`,
+		"lib/math.es": `export let double = fn(x) { x * 2 };`,
+	})
+	path := filepath.Join(dir, "main.es")
+	input, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := object.NewEnvironment()
+	env.SetImporter(path, NewModuleLoader())
+	testIntegerObject(t, Eval(testParseProgram(string(input)), env), 42)
+}
+
+func TestImportedStructTypeName(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"main.es": `import "geo.es";
+struct Point { x, y }
+let mine = Point(1, 2);
+let theirs = geo["origin"]();
+[type(mine), type(theirs), mine == theirs, type(theirs) == type(geo["Point"](1, 2))];`,
+		"geo.es": `export struct Point { x, y }
+export let origin = fn() { Point(0, 0) };`,
+	})
+	evaluated, errs := testEvalFile(t, filepath.Join(dir, "main.es"))
+	if len(errs) != 0 {
+		t.Fatalf("unexpected macro errors: %v", errs)
+	}
+	expected := "[Point, geo.Point, false, true]"
+	if evaluated.Inspect() != expected {
+		t.Errorf("wrong result. got=%q, want=%q", evaluated.Inspect(), expected)
+	}
+}
+
+func TestImportSearchPath(t *testing.T) {
+	libDir := writeModules(t, map[string]string{
+		"shared.es": `export let answer = 42;`,
+	})
+	dir := writeModules(t, map[string]string{
+		"main.es": `import "shared" as sh; sh["answer"];`,
+	})
+	modules := NewModuleLoader()
+	modules.SearchPath = []string{libDir}
+	evaluated, errs := testEvalFileWith(t, filepath.Join(dir, "main.es"), modules)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected macro errors: %v", errs)
+	}
+	testIntegerObject(t, evaluated, 42)
+}
+
+func TestImportErrors(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"missing.es": `import "nowhere.es";`,
+		"a.es":       `import "b.es";`,
+		"b.es":       `import "a.es";`,
+	})
+	tests := []struct {
+		file     string
+		expected string
+	}{
+		{"missing.es", `cannot find module "nowhere.es"`},
+		{"a.es", "import cycle: a.es -> b.es -> a.es"},
+	}
+	for _, tt := range tests {
+		_, errs := testEvalFile(t, filepath.Join(dir, tt.file))
+		if len(errs) != 1 {
+			t.Fatalf("wrong number of macro errors. got=%d, want=1", len(errs))
+		}
+		if errs[0].Kind != MACRO_ERR_IMPORT {
+			t.Errorf("wrong error kind. got=%q", errs[0].Kind)
+		}
+		if !strings.Contains(errs[0].Message, tt.expected) {
+			t.Errorf("error %q does not mention %q", errs[0].Message, tt.expected)
+		}
+	}
+}
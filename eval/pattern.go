@@ -0,0 +1,198 @@
+package eval
+
+import (
+	"fmt"
+	"learn-interpreter/ast"
+	"learn-interpreter/object"
+)
+
+func evalMatchExpression(node *ast.MatchExpression, env *object.Environment) object.Object {
+	subject := Eval(node.Subject, env)
+	if isError(subject) {
+		return subject
+	}
+	for _, arm := range node.Arms {
+		armEnv := object.NewEnclosedEnvironment(env)
+		matched, err := matchPattern(arm.Pattern, subject, armEnv)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+		if arm.Guard != nil {
+			guard := Eval(arm.Guard, armEnv)
+			if isError(guard) {
+				return guard
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+		return Eval(arm.Body, armEnv)
+	}
+	return newError("no match arm matches %s", subject.Inspect())
+}
+
+// matchPattern reports whether value has the shape of pattern, binding the
+// identifiers in pattern to the matching parts of value in env.
+func matchPattern(pattern ast.Expression, value object.Object, env *object.Environment) (bool, *object.Error) {
+	mismatch, err := bindPattern(pattern, value, env)
+	return mismatch == "", err
+}
+
+// destructure binds pattern like matchPattern, but reports a value of the
+// wrong shape as an error. It is used by let and by function parameters.
+func destructure(pattern ast.Expression, value object.Object, env *object.Environment) *object.Error {
+	mismatch, err := bindPattern(pattern, value, env)
+	if err != nil {
+		return err
+	}
+	if mismatch != "" {
+		return newError("cannot destructure %s into %s: %s", value.Inspect(), pattern.String(), mismatch)
+	}
+	return nil
+}
+
+// bindPattern binds the identifiers in pattern to the matching parts of
+// value in env. If value does not have the shape of pattern, it returns a
+// description of the first difference.
+func bindPattern(pattern ast.Expression, value object.Object, env *object.Environment) (string, *object.Error) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if pattern.Value != "_" {
+			env.Set(pattern.Value, value)
+		}
+		return "", nil
+	case *ast.DefaultPattern:
+		return bindPattern(pattern.Pattern, value, env)
+	case *ast.ArrayPattern:
+		return bindArrayPattern(pattern, value, env)
+	case *ast.HashPattern:
+		return bindHashPattern(pattern, value, env)
+	default:
+		literal := Eval(pattern, env)
+		if err, ok := literal.(*object.Error); ok {
+			return "", err
+		}
+		if !objectsEqual(literal, value) {
+			return fmt.Sprintf("expected %s, got %s", literal.Inspect(), value.Inspect()), nil
+		}
+		return "", nil
+	}
+}
+
+// bindMissing binds a pattern for which there is no value, which only
+// succeeds if the pattern has a default.
+func bindMissing(pattern ast.Expression, env *object.Environment, mismatch string) (string, *object.Error) {
+	def, ok := pattern.(*ast.DefaultPattern)
+	if !ok {
+		return mismatch, nil
+	}
+	value := Eval(def.Default, env)
+	if err, ok := value.(*object.Error); ok {
+		return "", err
+	}
+	return bindPattern(def.Pattern, value, env)
+}
+
+func bindArrayPattern(pattern *ast.ArrayPattern, value object.Object, env *object.Environment) (string, *object.Error) {
+	array, ok := value.(*object.Array)
+	if !ok {
+		return fmt.Sprintf("expected Array, got %s", value.Type()), nil
+	}
+	if pattern.Rest == nil && len(array.Elements) > len(pattern.Elements) {
+		return fmt.Sprintf("expected %d elements, got %d", len(pattern.Elements), len(array.Elements)), nil
+	}
+	for i, element := range pattern.Elements {
+		var mismatch string
+		var err *object.Error
+		if i < len(array.Elements) {
+			mismatch, err = bindPattern(element, array.Elements[i], env)
+		} else {
+			mismatch, err = bindMissing(element, env,
+				fmt.Sprintf("expected at least %d elements, got %d", i+1, len(array.Elements)))
+		}
+		if mismatch != "" || err != nil {
+			return mismatch, err
+		}
+	}
+	if pattern.Rest != nil {
+		rest := []object.Object{}
+		if len(array.Elements) > len(pattern.Elements) {
+			rest = append(rest, array.Elements[len(pattern.Elements):]...)
+		}
+		return bindPattern(pattern.Rest, &object.Array{Elements: rest}, env)
+	}
+	return "", nil
+}
+
+func bindHashPattern(pattern *ast.HashPattern, value object.Object, env *object.Environment) (string, *object.Error) {
+	if value.Type() != object.OBJ_TYPE_HASH && value.Type() != object.OBJ_TYPE_STRUCT {
+		return fmt.Sprintf("expected Hash, got %s", value.Type()), nil
+	}
+	for _, pair := range pattern.Pairs {
+		key := Eval(pair.Key, env)
+		if err, ok := key.(*object.Error); ok {
+			return "", err
+		}
+		var mismatch string
+		var err *object.Error
+		if field, ok := lookupKey(value, key); ok {
+			mismatch, err = bindPattern(pair.Value, field, env)
+		} else {
+			mismatch, err = bindMissing(pair.Value, env, fmt.Sprintf("missing key %s", key.Inspect()))
+		}
+		if mismatch != "" || err != nil {
+			return mismatch, err
+		}
+	}
+	return "", nil
+}
+
+// lookupKey finds key in a hash, or the field named key in a struct.
+func lookupKey(value, key object.Object) (object.Object, bool) {
+	switch value := value.(type) {
+	case *object.Hash:
+		hashable, ok := key.(object.Hashable)
+		if !ok {
+			return nil, false
+		}
+		pair, ok := value.Pairs[hashable.HashKey()]
+		return pair.Value, ok
+	case *object.Struct:
+		name, ok := key.(*object.String)
+		if !ok {
+			return nil, false
+		}
+		field, ok := value.Fields[name.Value]
+		return field, ok
+	default:
+		return nil, false
+	}
+}
+
+// patternNames lists the identifiers bound by pattern.
+func patternNames(pattern ast.Expression) []string {
+	names := []string{}
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if pattern.Value != "_" {
+			names = append(names, pattern.Value)
+		}
+	case *ast.DefaultPattern:
+		names = append(names, patternNames(pattern.Pattern)...)
+	case *ast.ArrayPattern:
+		for _, element := range pattern.Elements {
+			names = append(names, patternNames(element)...)
+		}
+		if pattern.Rest != nil {
+			names = append(names, patternNames(pattern.Rest)...)
+		}
+	case *ast.HashPattern:
+		for _, pair := range pattern.Pairs {
+			names = append(names, patternNames(pair.Value)...)
+		}
+	}
+	return names
+}
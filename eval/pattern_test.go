@@ -0,0 +1,85 @@
+package eval
+
+import (
+	"testing"
+)
+
+func TestMatchExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`match (1) { 1 => "one", _ => "other" }`, "one"},
+		{`match (2) { 1 => "one", _ => "other" }`, "other"},
+		{`match ("a") { "a" => 1, "b" => 2 }`, "1"},
+		{`match (null) { null => "nothing" }`, "nothing"},
+		{`match (5) { n => n * 2 }`, "10"},
+		{`match (-3) { n if n > 0 => "pos", -3 => "minus three" }`, "minus three"},
+		{`match ([1, 2, 3]) { [] => 0, [head, ...tail] => tail }`, "[2, 3]"},
+		{`match ([]) { [] => "empty", [x, ...rest] => x }`, "empty"},
+		{`match ([1, 2]) { [a] => a, [a, b] => a + b }`, "3"},
+		{`match ([1, [2, 3]]) { [_, [x, y]] => x * y }`, "6"},
+		{`match ({"kind": "circle", "r": 2}) { {"kind": "square"} => 0, {"kind": "circle", r} => r }`, "2"},
+		{`match ({"a": 1}) { {b} => b, _ => "no b" }`, "no b"},
+		{`struct Point { x, y } match (Point(0, 4)) { {x: 0, y} => y }`, "4"},
+		{`let x = 1; match (2) { x => x }; x`, "1"},
+		{`match (4) { n if n > 3 => { let half = n / 2; half } }`, "2"},
+		{`match (3) { 1 => 1 }`, "ERROR: no match arm matches 3"},
+		{`match (3) { n if m => 1 }`, "ERROR: identifier not found: m"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestDestructuringLet(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let [a, b, ...rest] = [1, 2, 3, 4]; [a, b, rest]`, "[1, 2, [3, 4]]"},
+		{`let [a, ...rest] = [1]; rest`, "[]"},
+		{`let [_, second] = [1, 2]; second`, "2"},
+		{`let {"age": years} = {"name": "Ann", "age": 30}; years`, "30"},
+		{`let {name, age: years} = {"name": "Ann", "age": 30}; [name, years]`, "[Ann, 30]"},
+		{`let {pos: [x, y], tags: {first}} = {"pos": [1, 2], "tags": {"first": "a"}}; [x, y, first]`, "[1, 2, a]"},
+		{`let [x, y = x + 10] = [1]; y`, "11"},
+		{`let {name = "anon"} = {}; name`, "anon"},
+		{`let {name = "anon"} = {"name": null}; name`, "null"},
+		{`struct Point { x, y } let {x, y} = Point(3, 4); x * y`, "12"},
+		{`let [a, b] = [1];`, "ERROR: cannot destructure [1] into [a, b]: expected at least 2 elements, got 1"},
+		{`let [a] = [1, 2];`, "ERROR: cannot destructure [1, 2] into [a]: expected 1 elements, got 2"},
+		{`let [a] = 5;`, "ERROR: cannot destructure 5 into [a]: expected Array, got Integer"},
+		{`let {name} = {"age": 1};`, "ERROR: cannot destructure {age: 1} into {name: name}: missing key name"},
+		{`let {a} = [1];`, "ERROR: cannot destructure [1] into {a: a}: expected Hash, got Array"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestDestructuringParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let f = fn([a, b]) { a + b }; f([1, 2])`, "3"},
+		{`let f = fn({name, age: years}) { years }; f({"name": "Ann", "age": 30})`, "30"},
+		{`let f = fn(x, y = x * 2) { x + y }; f(1)`, "3"},
+		{`let f = fn(x, y = x * 2) { x + y }; f(1, 1)`, "2"},
+		{`let f = fn([a, b]) { a }; f(1)`, "ERROR: cannot destructure 1 into [a, b]: expected Array, got Integer"},
+		{`let f = fn(x, y) { x }; f(1)`, "ERROR: wrong number of arguments. got=1, want=2"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
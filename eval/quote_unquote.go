@@ -8,13 +8,21 @@ import (
 )
 
 func quote(node ast.Node, env *object.Environment) object.Object {
-	node = evalUnquoteCalls(node, env)
+	node, err := evalUnquoteCalls(ast.Copy(node), env)
+	if err != nil {
+		return err
+	}
 	return &object.Quote{Node: node}
 }
 
-func evalUnquoteCalls(quoted ast.Node, env *object.Environment) ast.Node {
-	return ast.Modify(quoted, func(node ast.Node) ast.Node {
+func evalUnquoteCalls(quoted ast.Node, env *object.Environment) (ast.Node, *object.Error) {
+	var err *object.Error
+	node := ast.Modify(quoted, func(node ast.Node) ast.Node {
+		if err != nil {
+			return node
+		}
 		if !isUnquoteCall(node) {
+			err = spliceUnquotedLists(node, env)
 			return node
 		}
 		call, ok := node.(*ast.CallExpression)
@@ -25,8 +33,123 @@ func evalUnquoteCalls(quoted ast.Node, env *object.Environment) ast.Node {
 			return node
 		}
 		unquoted := Eval(call.Arguments[0], env)
-		return convertObjectToASTNode(unquoted)
+		if isError(unquoted) {
+			err = unquoted.(*object.Error)
+			return node
+		}
+		// Only the quoted node itself may become a statement; anywhere
+		// inside it an unquote stands in an expression position.
+		if node == quoted {
+			converted, convertErr := convertObjectToASTNode(unquoted)
+			if convertErr != nil {
+				err = convertErr
+				return node
+			}
+			return converted
+		}
+		converted, convertErr := convertObjectToExpression(unquoted)
+		if convertErr != nil {
+			err = convertErr
+			return node
+		}
+		return converted
 	})
+	if err == nil {
+		ast.Modify(node, func(node ast.Node) ast.Node {
+			if isUnquoteSpliceCall(node) && err == nil {
+				err = newError("unquote_splice is only allowed in argument lists, array literals and blocks")
+			}
+			return node
+		})
+	}
+	return node, err
+}
+
+// spliceUnquotedLists flattens the unquote_splice(expr) calls that appear
+// directly inside the argument list, array literal or block node.
+func spliceUnquotedLists(node ast.Node, env *object.Environment) *object.Error {
+	switch node := node.(type) {
+	case *ast.CallExpression:
+		args, err := spliceExpressions(node.Arguments, env)
+		if err != nil {
+			return err
+		}
+		node.Arguments = args
+	case *ast.ArrayLiteral:
+		elements, err := spliceExpressions(node.Elements, env)
+		if err != nil {
+			return err
+		}
+		node.Elements = elements
+	case *ast.BlockStatement:
+		statements := []ast.Statement{}
+		for _, stmt := range node.Statements {
+			exprStmt, ok := stmt.(*ast.ExpressionStatement)
+			if !ok || !isUnquoteSpliceCall(exprStmt.Expression) {
+				statements = append(statements, stmt)
+				continue
+			}
+			nodes, err := evalUnquoteSplice(exprStmt.Expression.(*ast.CallExpression), env)
+			if err != nil {
+				return err
+			}
+			for _, n := range nodes {
+				switch n := n.(type) {
+				case ast.Statement:
+					statements = append(statements, n)
+				case ast.Expression:
+					statements = append(statements, &ast.ExpressionStatement{Token: exprStmt.Token, Expression: n})
+				}
+			}
+		}
+		node.Statements = statements
+	}
+	return nil
+}
+
+func spliceExpressions(exps []ast.Expression, env *object.Environment) ([]ast.Expression, *object.Error) {
+	result := []ast.Expression{}
+	for _, exp := range exps {
+		if !isUnquoteSpliceCall(exp) {
+			result = append(result, exp)
+			continue
+		}
+		nodes, err := evalUnquoteSplice(exp.(*ast.CallExpression), env)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range nodes {
+			e, ok := n.(ast.Expression)
+			if !ok {
+				return nil, newError("cannot splice statement %s into an expression list", n.String())
+			}
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+func evalUnquoteSplice(call *ast.CallExpression, env *object.Environment) ([]ast.Node, *object.Error) {
+	if len(call.Arguments) != 1 {
+		return nil, newError("wrong number of arguments to unquote_splice. got=%d, want=1", len(call.Arguments))
+	}
+	evaluated := Eval(call.Arguments[0], env)
+	if isError(evaluated) {
+		return nil, evaluated.(*object.Error)
+	}
+	array, ok := evaluated.(*object.Array)
+	if !ok {
+		return nil, newError("argument to `unquote_splice` must be ARRAY, got %s", evaluated.Type())
+	}
+	nodes := []ast.Node{}
+	for _, el := range array.Elements {
+		node, err := convertObjectToASTNode(el)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
 }
 
 func isUnquoteCall(node ast.Node) bool {
@@ -37,14 +160,28 @@ func isUnquoteCall(node ast.Node) bool {
 	return callExpression.Function.TokenLiteral() == "unquote"
 }
 
-func convertObjectToASTNode(obj object.Object) ast.Node {
+func isUnquoteSpliceCall(node ast.Node) bool {
+	callExpression, ok := node.(*ast.CallExpression)
+	if !ok {
+		return false
+	}
+	return callExpression.Function.TokenLiteral() == "unquote_splice"
+}
+
+// convertObjectToASTNode turns a value back into a literal that evaluates to
+// an equivalent value. Functions become function literals, so they lose the
+// environment they closed over.
+func convertObjectToASTNode(obj object.Object) (ast.Node, *object.Error) {
 	switch obj := obj.(type) {
 	case *object.Integer:
 		t := token.Token{
 			Type:    token.INT,
 			Literal: fmt.Sprintf("%d", obj.Value),
 		}
-		return &ast.IntegerLiteral{Token: t, Value: obj.Value}
+		return &ast.IntegerLiteral{Token: t, Value: obj.Value}, nil
+	case *object.BigInt:
+		t := token.Token{Type: token.INT, Literal: obj.Value.String()}
+		return &ast.IntegerLiteral{Token: t, Big: obj.Value}, nil
 	case *object.Boolean:
 		var t token.Token
 		if obj.Value {
@@ -52,12 +189,62 @@ func convertObjectToASTNode(obj object.Object) ast.Node {
 		} else {
 			t = token.Token{Type: token.FALSE, Literal: "false"}
 		}
-		return &ast.BooleanLiteral{Token: t, Value: obj.Value}
+		return &ast.BooleanLiteral{Token: t, Value: obj.Value}, nil
+	case *object.String:
+		t := token.Token{Type: token.STRING, Literal: obj.Value}
+		return &ast.StringLiteral{Token: t, Value: obj.Value}, nil
+	case *object.Null:
+		t := token.Token{Type: token.NULL, Literal: "null"}
+		return &ast.NullLiteral{Token: t}, nil
+	case *object.Array:
+		t := token.Token{Type: token.LBRACKET, Literal: "["}
+		elements := []ast.Expression{}
+		for _, el := range obj.Elements {
+			exp, err := convertObjectToExpression(el)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, exp)
+		}
+		return &ast.ArrayLiteral{Token: t, Elements: elements}, nil
+	case *object.Hash:
+		t := token.Token{Type: token.LBRACE, Literal: "{"}
+		pairs := make(map[ast.Expression]ast.Expression)
+		for _, pair := range obj.Pairs {
+			key, err := convertObjectToExpression(pair.Key)
+			if err != nil {
+				return nil, err
+			}
+			value, err := convertObjectToExpression(pair.Value)
+			if err != nil {
+				return nil, err
+			}
+			pairs[key] = value
+		}
+		return &ast.HashLiteral{Token: t, Pairs: pairs}, nil
+	case *object.Function:
+		t := token.Token{Type: token.FUNCTION, Literal: "fn"}
+		body, _ := ast.Copy(obj.Body).(*ast.BlockStatement)
+		return &ast.FunctionLiteral{Token: t, Parameters: obj.Parameters, Rest: obj.Rest, Body: body}, nil
 	case *object.Quote:
-		return obj.Node
+		return ast.Copy(obj.Node), nil
 	default:
-		return nil
+		return nil, newError("cannot unquote %s: value has no literal representation", obj.Type())
+	}
+}
+
+// convertObjectToExpression is convertObjectToASTNode for positions that
+// need an expression, such as the elements of an array literal.
+func convertObjectToExpression(obj object.Object) (ast.Expression, *object.Error) {
+	node, err := convertObjectToASTNode(obj)
+	if err != nil {
+		return nil, err
+	}
+	exp, ok := node.(ast.Expression)
+	if !ok {
+		return nil, newError("cannot unquote %s into an expression", nodeKind(node))
 	}
+	return exp, nil
 }
 
 //let unless = macro(condition, consequence, alternative){ quote(if (!(unquote(condition))) { unquote(consequence); }else { unquote(alternative); }); };
@@ -103,3 +103,111 @@ quote(foobar)`,
 		}
 	}
 }
+
+func TestQuoteUnquoteValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(unquote("hello"))`, `hello`},
+		{`quote(unquote([1, "two", true]))`, `[1, two, true]`},
+		{`quote(unquote({"a": [1]}))`, `{a:[1]}`},
+		{`quote(unquote(null))`, `null`},
+		{`quote(unquote(if (false) { 1 }))`, `null`},
+		{`let double = fn(x) { x * 2 }; quote(unquote(double))`, `fn(x) (x * 2)`},
+		{`quote(f(unquote(1 + 1), unquote("s")))`, `f(2, s)`},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		quote, ok := evaluated.(*object.Quote)
+		if !ok {
+			t.Fatalf("expected *object.Quote. got=%T (%+v)",
+				evaluated, evaluated)
+		}
+		if quote.Node.String() != tt.expected {
+			t.Errorf("not equal. got=%q, want=%q",
+				quote.Node.String(), tt.expected)
+		}
+	}
+}
+
+func TestQuoteUnquoteEvaluatesBack(t *testing.T) {
+	input := `
+let m = macro() { let table = {"one": 1, "two": [2, 2]}; quote(unquote(table)) };
+m()["two"][1] + m()["one"];
+`
+	program := testParseProgram(input)
+	env := object.NewEnvironment()
+	expanded, errs := ExpandMacros(program, env)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected macro errors: %v", errs)
+	}
+	testIntegerObject(t, Eval(expanded, object.NewEnvironment()), 3)
+}
+
+func TestUnquoteErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(unquote(len))`, "cannot unquote BuiltIn: value has no literal representation"},
+		{`quote(unquote(foo))`, "identifier not found: foo"},
+		{`let q = quote(if (true) { 1 }); quote(unquote(node_children(q)))`, "cannot unquote BlockStatement into an expression"},
+		{`let q = quote(if (true) { 1 }); quote(unquote({"k": node_children(q)[1]}))`, "cannot unquote BlockStatement into an expression"},
+		{`let q = quote(if (true) { 1 }); quote(f(unquote(node_children(q)[1])))`, "cannot unquote BlockStatement into an expression"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Fatalf("expected *object.Error. got=%T (%+v)", evaluated, evaluated)
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. got=%q, want=%q", errObj.Message, tt.expected)
+		}
+	}
+}
+
+func TestQuoteUnquoteSplice(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(f(0, unquote_splice([1, 2]), 3))`, `f(0, 1, 2, 3)`},
+		{`quote([unquote_splice([quote(a), quote(b + c)])])`, `[a, (b + c)]`},
+		{`quote(f(unquote_splice([])))`, `f()`},
+		{`quote(fn() { unquote_splice([quote(a), quote(b + 1)]) })`, `fn() a(b + 1)`},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		quote, ok := evaluated.(*object.Quote)
+		if !ok {
+			t.Fatalf("expected *object.Quote. got=%T (%+v)",
+				evaluated, evaluated)
+		}
+		if quote.Node.String() != tt.expected {
+			t.Errorf("not equal. got=%q, want=%q",
+				quote.Node.String(), tt.expected)
+		}
+	}
+}
+
+func TestQuoteUnquoteSpliceErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(unquote_splice([1]))`, "unquote_splice is only allowed in argument lists, array literals and blocks"},
+		{`quote(f(unquote_splice(1)))`, "argument to `unquote_splice` must be ARRAY, got Integer"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Fatalf("expected *object.Error. got=%T (%+v)", evaluated, evaluated)
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. got=%q, want=%q", errObj.Message, tt.expected)
+		}
+	}
+}
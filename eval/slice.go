@@ -0,0 +1,125 @@
+package eval
+
+import (
+	"learn-interpreter/ast"
+	"learn-interpreter/object"
+)
+
+// normalizeIndex turns a negative index, which counts from the end, into
+// an offset from the start. It reports false if idx is out of range.
+func normalizeIndex(idx int64, length int) (int, bool) {
+	if idx < 0 {
+		idx += int64(length)
+	}
+	if idx < 0 || idx >= int64(length) {
+		return 0, false
+	}
+	return int(idx), true
+}
+
+// evalStringIndexExpression returns the character at index as a string.
+// Strings are indexed by rune, not by byte.
+func evalStringIndexExpression(str, index object.Object) object.Object {
+	runes := []rune(str.(*object.String).Value)
+	idx, ok := normalizeIndex(index.(*object.Integer).Value, len(runes))
+	if !ok {
+		return NULL
+	}
+	return &object.String{Value: string(runes[idx])}
+}
+
+func evalSliceExpression(node *ast.SliceExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+	bounds := [3]*int64{}
+	for i, exp := range []ast.Expression{node.Start, node.End, node.Step} {
+		if exp == nil {
+			continue
+		}
+		bound := Eval(exp, env)
+		if isError(bound) {
+			return bound
+		}
+		switch bound := bound.(type) {
+		case *object.Integer:
+			bounds[i] = &bound.Value
+		case *object.Null:
+		default:
+			return newError("slice bound must be INTEGER, got %s", bound.Type())
+		}
+	}
+	start, end, step := bounds[0], bounds[1], bounds[2]
+	if step != nil && *step == 0 {
+		return newError("slice step cannot be zero")
+	}
+
+	switch left := left.(type) {
+	case *object.Array:
+		elements := []object.Object{}
+		for _, i := range sliceIndices(len(left.Elements), start, end, step) {
+			elements = append(elements, left.Elements[i])
+		}
+		return &object.Array{Elements: elements}
+	case *object.String:
+		runes := []rune(left.Value)
+		sliced := []rune{}
+		for _, i := range sliceIndices(len(runes), start, end, step) {
+			sliced = append(sliced, runes[i])
+		}
+		return &object.String{Value: string(sliced)}
+	default:
+		return newError("slice operator not supported: %s", left.Type())
+	}
+}
+
+// sliceIndices lists the offsets selected by start:end:step in a sequence
+// of the given length. As with Python slices, negative bounds count from
+// the end, bounds past either end are clamped, and a negative step walks
+// backwards from start down to, but not including, end.
+func sliceIndices(length int, start, end, step *int64) []int {
+	s := int64(1)
+	if step != nil {
+		s = *step
+	}
+	n := int64(length)
+	bound := func(b *int64, def, lo, hi int64) int64 {
+		if b == nil {
+			return def
+		}
+		v := *b
+		if v < 0 {
+			v += n
+		}
+		if v < lo {
+			return lo
+		}
+		if v > hi {
+			return hi
+		}
+		return v
+	}
+
+	// A step longer than the sequence selects the same indices as a step of
+	// its length, which keeps i += s below from overflowing.
+	if n > 0 && s > n {
+		s = n
+	} else if n > 0 && s < -n {
+		s = -n
+	}
+
+	indices := []int{}
+	if s > 0 {
+		from, to := bound(start, 0, 0, n), bound(end, n, 0, n)
+		for i := from; i < to; i += s {
+			indices = append(indices, int(i))
+		}
+	} else {
+		from, to := bound(start, n-1, -1, n-1), bound(end, -1, -1, n-1)
+		for i := from; i > to; i += s {
+			indices = append(indices, int(i))
+		}
+	}
+	return indices
+}
@@ -0,0 +1,63 @@
+package eval
+
+import (
+	"testing"
+)
+
+func TestStringIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"hello"[0]`, "h"},
+		{`"hello"[-1]`, "o"},
+		{`"héllo"[1]`, "é"},
+		{`"日本語"[2]`, "語"},
+		{`len("日本語")`, "3"},
+		{`"abc"[3]`, "null"},
+		{`"abc"[-4]`, "null"},
+		{`"abc"["x"]`, "ERROR: index operator not supported: String"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1, 2, 3, 4, 5][1:3]`, "[2, 3]"},
+		{`[1, 2, 3, 4, 5][:2]`, "[1, 2]"},
+		{`[1, 2, 3, 4, 5][3:]`, "[4, 5]"},
+		{`[1, 2, 3, 4, 5][:]`, "[1, 2, 3, 4, 5]"},
+		{`[1, 2, 3, 4, 5][-2:]`, "[4, 5]"},
+		{`[1, 2, 3, 4, 5][:-2]`, "[1, 2, 3]"},
+		{`[1, 2, 3, 4, 5][::2]`, "[1, 3, 5]"},
+		{`[1, 2, 3, 4, 5][::-1]`, "[5, 4, 3, 2, 1]"},
+		{`[1, 2, 3, 4, 5][3:0:-1]`, "[4, 3, 2]"},
+		{`[1, 2, 3][1:100]`, "[2, 3]"},
+		{`[1, 2, 3][2:1]`, "[]"},
+		{`[1, 2, 3][null:2]`, "[1, 2]"},
+		{`"hello"[1:4]`, "ell"},
+		{`"héllo"[::-1]`, "olléh"},
+		{`"hello"[-3:]`, "llo"},
+		{`[1, 2, 3][1::9223372036854775807]`, "[2]"},
+		{`[1, 2, 3][1::-9223372036854775807]`, "[2]"},
+		{`"abc"[::-9223372036854775807]`, "c"},
+		{`[1, 2][::0]`, "ERROR: slice step cannot be zero"},
+		{`[1, 2]["a":]`, "ERROR: slice bound must be INTEGER, got String"},
+		{`5[1:2]`, "ERROR: slice operator not supported: Integer"},
+		{`let a = [1, 2, 3]; a[-1] = 9; a`, "[1, 2, 9]"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package eval
+
+import (
+	"learn-interpreter/ast"
+	"learn-interpreter/object"
+)
+
+func evalStructStatement(node *ast.StructStatement, env *object.Environment) object.Object {
+	structType := &object.StructType{Name: node.Name.Value, Fields: []string{}}
+	for _, field := range node.Fields {
+		if structType.HasField(field.Value) {
+			return newError("duplicate field %s in struct %s", field.Value, structType.Name)
+		}
+		structType.Fields = append(structType.Fields, field.Value)
+	}
+	if err := checkDeclaration(env, []string{structType.Name}, false); err != nil {
+		return err
+	}
+	env.Set(structType.Name, structType)
+	return nil
+}
+
+// newStruct is the constructor of a struct type: it takes the field values
+// in declaration order, or by name.
+func newStruct(structType *object.StructType, args []object.Object, named map[string]object.Object) object.Object {
+	for name := range named {
+		idx := fieldIndex(structType, name)
+		if idx < 0 {
+			return newError("%s has no field %s", structType.Name, name)
+		}
+		if idx < len(args) {
+			return newError("field %s given twice", name)
+		}
+	}
+	if len(args)+len(named) != len(structType.Fields) {
+		return newError("wrong number of arguments to %s. got=%d, want=%d",
+			structType.Name, len(args)+len(named), len(structType.Fields))
+	}
+	fields := make(map[string]object.Object, len(structType.Fields))
+	for i, name := range structType.Fields {
+		if i < len(args) {
+			fields[name] = args[i]
+		} else if value, ok := named[name]; ok {
+			fields[name] = value
+		} else {
+			return newError("missing field %s for %s", name, structType.Name)
+		}
+	}
+	return &object.Struct{StructType: structType, Fields: fields}
+}
+
+func fieldIndex(structType *object.StructType, name string) int {
+	for i, field := range structType.Fields {
+		if field == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func evalStructField(s *object.Struct, name string) object.Object {
+	value, ok := s.Fields[name]
+	if !ok {
+		return newError("%s has no field %s", s.StructType.Name, name)
+	}
+	return value
+}
+
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		if env.IsConst(target.Value) {
+			return newError("cannot assign to constant %s", target.Value)
+		}
+		if !env.Assign(target.Value, val) {
+			return newError("identifier not found: " + target.Value)
+		}
+	case *ast.MemberExpression:
+		obj := Eval(target.Object, env)
+		if isError(obj) {
+			return obj
+		}
+		if err := assignIndex(obj, &object.String{Value: target.Property.Value}, val); err != nil {
+			return err
+		}
+	case *ast.IndexExpression:
+		obj := Eval(target.Left, env)
+		if isError(obj) {
+			return obj
+		}
+		index := Eval(target.Index, env)
+		if isError(index) {
+			return index
+		}
+		if err := assignIndex(obj, index, val); err != nil {
+			return err
+		}
+	default:
+		return newError("cannot assign to %s", node.Target.String())
+	}
+	return val
+}
+
+// assignIndex stores val under index in a struct, hash or array.
+func assignIndex(obj, index, val object.Object) *object.Error {
+	switch obj := obj.(type) {
+	case *object.Struct:
+		if obj.Frozen {
+			return newError("cannot modify frozen %s", obj.StructType.Name)
+		}
+		name, ok := index.(*object.String)
+		if !ok {
+			return newError("field name must be STRING, got %s", index.Type())
+		}
+		if !obj.StructType.HasField(name.Value) {
+			return newError("%s has no field %s", obj.StructType.Name, name.Value)
+		}
+		obj.Fields[name.Value] = val
+	case *object.Hash:
+		if obj.Frozen {
+			return newError("cannot modify frozen Hash")
+		}
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+		obj.Pairs[key.HashKey()] = object.HashPair{Key: index, Value: val}
+	case *object.Array:
+		if obj.Frozen {
+			return newError("cannot modify frozen Array")
+		}
+		idx, ok := index.(*object.Integer)
+		if !ok {
+			return newError("array index must be INTEGER, got %s", index.Type())
+		}
+		i, ok := normalizeIndex(idx.Value, len(obj.Elements))
+		if !ok {
+			return newError("index out of range: %d", idx.Value)
+		}
+		obj.Elements[i] = val
+	default:
+		return newError("cannot assign to a member of %s", obj.Type())
+	}
+	return nil
+}
+
+// objectsEqual compares values structurally: structs of the same type are
+// equal when all their fields are, arrays and hashes element by element.
+func objectsEqual(left, right object.Object) bool {
+	switch left := left.(type) {
+	case *object.Integer:
+		right, ok := right.(*object.Integer)
+		return ok && left.Value == right.Value
+	case *object.BigInt:
+		right, ok := right.(*object.BigInt)
+		return ok && left.Value.Cmp(right.Value) == 0
+	case *object.String:
+		right, ok := right.(*object.String)
+		return ok && left.Value == right.Value
+	case *object.Array:
+		right, ok := right.(*object.Array)
+		if !ok || len(left.Elements) != len(right.Elements) {
+			return false
+		}
+		for i := range left.Elements {
+			if !objectsEqual(left.Elements[i], right.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	case *object.Hash:
+		right, ok := right.(*object.Hash)
+		if !ok || len(left.Pairs) != len(right.Pairs) {
+			return false
+		}
+		for key, pair := range left.Pairs {
+			other, ok := right.Pairs[key]
+			if !ok || !objectsEqual(pair.Value, other.Value) {
+				return false
+			}
+		}
+		return true
+	case *object.Struct:
+		right, ok := right.(*object.Struct)
+		if !ok || left.StructType != right.StructType {
+			return false
+		}
+		for _, name := range left.StructType.Fields {
+			if !objectsEqual(left.Fields[name], right.Fields[name]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return left == right
+	}
+}
+
+func evalStructInfixExpression(operator string, left, right object.Object) object.Object {
+	switch operator {
+	case "==":
+		return nativeBoolToBooleanObject(objectsEqual(left, right))
+	case "!=":
+		return nativeBoolToBooleanObject(!objectsEqual(left, right))
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// typeName is what the type builtin reports: the declared name for struct
+// values, qualified by the module declaring it if there is one, and the
+// object type for everything else.
+func typeName(obj object.Object) string {
+	if s, ok := obj.(*object.Struct); ok {
+		if s.StructType.Module != "" {
+			return s.StructType.Module + "." + s.StructType.Name
+		}
+		return s.StructType.Name
+	}
+	return string(obj.Type())
+}
+
+func init() {
+	builtins["type"] = &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			return &object.String{Value: typeName(args[0])}
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package eval
+
+import (
+	"testing"
+)
+
+func TestStructs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`struct Point { x, y } Point(1, 2)`, "Point{x: 1, y: 2}"},
+		{`struct Point { x, y } let p = Point(1, 2); p.x + p.y`, "3"},
+		{`struct Point { x, y } let p = Point(1, 2); p.x = 10; p`, "Point{x: 10, y: 2}"},
+		{`struct Point { x, y } Point(1, 2).z`, "ERROR: Point has no field z"},
+		{`struct Point { x, y } let p = Point(1, 2); p.z = 3`, "ERROR: Point has no field z"},
+		{`struct Point { x, y } Point(1)`, "ERROR: wrong number of arguments to Point. got=1, want=2"},
+		{`struct Point { x, y } Point(1, x: 2)`, "ERROR: field x given twice"},
+		{`struct Point { x, y } Point(1, z: 2)`, "ERROR: Point has no field z"},
+		{`struct Point { x, x }`, "ERROR: duplicate field x in struct Point"},
+		{`struct Point { x, y } Point(1, [2]) == Point(1, [2])`, "true"},
+		{`struct Point { x, y } Point(1, 2) != Point(2, 1)`, "true"},
+		{`struct A { v } struct B { v } A(1) == B(1)`, "false"},
+		{`struct Point { x, y } type(Point(1, 2))`, "Point"},
+		{`struct Point { x, y } type(Point)`, "StructType"},
+		{`type(1)`, "Integer"},
+		{`struct Counter { n, inc } let c = Counter(1, fn() { self.n = self.n + 1 }); c.inc(); c.n`, "2"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+func TestAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let x = 1; x = x + 1; x`, "2"},
+		{`let x = 1; let f = fn() { x = 5 }; f(); x`, "5"},
+		{`let a = [1, 2]; a[1] = 3; a`, "[1, 3]"},
+		{`let h = {"a": 1}; h.a = 2; h["a"]`, "2"},
+		{`y = 1`, "ERROR: identifier not found: y"},
+		{`let a = [1]; a[5] = 1`, "ERROR: index out of range: 5"},
+		{`let s = "x"; s.a = 1`, "ERROR: cannot assign to a member of String"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
@@ -1,3 +1,5 @@
 module learn-interpreter
 
 go 1.21
+
+require golang.org/x/text v0.21.0
@@ -1,50 +1,185 @@
 package lexer
 
 import (
+	"fmt"
+	"io"
 	"learn-interpreter/token"
 	"strings"
 	"unicode"
 	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 type Lexer struct {
-	input        string
+	// input holds the source read so far that has not been discarded;
+	// offset is the byte offset of its first byte in the whole source.
+	input  string
+	offset int
+	// reader supplies the rest of the source, until eof is set.
+	reader io.Reader
+	eof    bool
+	file   string
+
 	position     int
 	readPosition int
 	char         rune
 	line         int
-	row          int
+	column       int
+	// templates holds, for each interpolation being lexed, how many braces
+	// are open inside it, so that the } closing it can be told apart.
+	templates []int
+	errors    []string
 }
 
+// chunkSize is how many bytes NewReader's lexer asks its reader for at a
+// time.
+const chunkSize = 4096
+
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	l := &Lexer{input: input, eof: true, line: 1}
 	l.readChar()
 	return l
 }
 
+// NewReader returns a lexer that reads its source from r as tokens are
+// asked for, rather than all at once. file is recorded in every token and
+// error message; it may be empty.
+func NewReader(r io.Reader, file string) *Lexer {
+	l := &Lexer{reader: r, file: file, line: 1}
+	l.readChar()
+	return l
+}
+
+// Tokens reads the remaining tokens, up to and including EOF.
+func (l *Lexer) Tokens() []token.Token {
+	tokens := []token.Token{}
+	for {
+		t := l.NextToken()
+		tokens = append(tokens, t)
+		if t.Type == token.EOF {
+			return tokens
+		}
+	}
+}
+
+// Errors lists the malformed input found so far. Each malformed token is
+// returned as ILLEGAL and lexing carries on after it.
+func (l *Lexer) Errors() []string {
+	return l.errors
+}
+
+func (l *Lexer) errorf(at token.Token, format string, a ...interface{}) {
+	msg := at.Position() + ": " + fmt.Sprintf(format, a...)
+	l.errors = append(l.errors, msg)
+}
+
+// fill reads from the reader until at least n bytes follow readPosition
+// or the source is exhausted. A read error ends the source like EOF does,
+// and is reported as a lexer error.
+func (l *Lexer) fill(n int) {
+	for !l.eof && len(l.input)-l.readPosition < n {
+		buf := make([]byte, chunkSize)
+		read, err := l.reader.Read(buf)
+		l.input += string(buf[:read])
+		if err == io.EOF {
+			l.eof = true
+		} else if err != nil {
+			l.eof = true
+			l.errorf(l.here(), "read error: %s", err)
+		}
+	}
+}
+
+// discard drops the input before the current character, which no token
+// being read can refer to any more.
+func (l *Lexer) discard() {
+	if l.position > len(l.input) {
+		return
+	}
+	l.input = l.input[l.position:]
+	l.offset += l.position
+	l.readPosition -= l.position
+	l.position = 0
+}
+
+// here returns a token positioned at the current character.
+func (l *Lexer) here() token.Token {
+	return token.Token{
+		Line:   l.line,
+		Column: l.column,
+		Offset: l.offset + l.position,
+		File:   l.file,
+	}
+}
+
 func (l *Lexer) newToken(tokenType token.TokenType, ch rune) token.Token {
 	c := string(ch)
 	if ch == 0 {
 		c = ""
 	}
-	return token.Token{Type: tokenType, Literal: c, Line: l.line, Row: l.row}
+	t := l.here()
+	t.Type = tokenType
+	t.Literal = c
+	return t
 }
 
+// skipWhitespace skips whitespace and comments, which run from // to the
+// end of the line or from /* to the next */.
 func (l *Lexer) skipWhitespace() {
-	for strings.ContainsRune(" \t\n\r", l.char) {
-		if l.char == '\n' {
-			l.line += 1
-			l.row = 0
+	for {
+		switch {
+		case strings.ContainsRune(" \t\n\r", l.char):
+			l.readChar()
+		case l.char == '/' && l.peekChar() == '/':
+			for l.char != '\n' && l.char != 0 {
+				l.readChar()
+			}
+		case l.char == '/' && l.peekChar() == '*':
+			l.skipBlockComment()
+		default:
+			return
+		}
+	}
+}
+
+func (l *Lexer) skipBlockComment() {
+	start := l.here()
+	l.readChar()
+	l.readChar()
+	for !(l.char == '*' && l.peekChar() == '/') {
+		if l.char == 0 {
+			l.errorf(start, "unterminated comment")
+			return
 		}
 		l.readChar()
 	}
+	l.readChar()
+	l.readChar()
 }
 
+// isLetter reports whether ch can start an identifier: any Unicode letter
+// or an underscore.
 func isLetter(ch rune) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+	return unicode.IsLetter(ch) || ch == '_'
 }
 
+// isIdentifierChar reports whether ch can continue an identifier: a letter,
+// an underscore, a Unicode decimal digit or a combining mark. Marks are
+// needed by scripts such as Devanagari, and by input that spells accented
+// letters in decomposed form.
+func isIdentifierChar(ch rune) bool {
+	return isLetter(ch) || unicode.IsDigit(ch) || unicode.In(ch, unicode.Mn, unicode.Mc)
+}
+
+// readChar advances to the next character, keeping line and column up to
+// date.
 func (l *Lexer) readChar() {
+	if l.char == '\n' {
+		l.line += 1
+		l.column = 0
+	}
+	l.fill(utf8.UTFMax)
 	var width int
 	if l.readPosition >= len(l.input) {
 		l.char = 0
@@ -56,10 +191,11 @@ func (l *Lexer) readChar() {
 	}
 	l.position = l.readPosition
 	l.readPosition += width
-	l.row += 1
+	l.column += 1
 }
 
 func (l *Lexer) peekChar() rune {
+	l.fill(utf8.UTFMax)
 	if l.readPosition >= len(l.input) {
 		return 0
 	} else {
@@ -68,65 +204,123 @@ func (l *Lexer) peekChar() rune {
 	}
 }
 
+// peekCharN returns the rune n positions after the current one.
+func (l *Lexer) peekCharN(n int) rune {
+	l.fill(n * utf8.UTFMax)
+	position := l.readPosition
+	for ; n > 1; n-- {
+		if position >= len(l.input) {
+			return 0
+		}
+		_, w := utf8.DecodeRuneInString(l.input[position:])
+		position += w
+	}
+	if position >= len(l.input) {
+		return 0
+	}
+	runeValue, _ := utf8.DecodeRuneInString(l.input[position:])
+	return runeValue
+}
+
+// readIdentifier reads an identifier and returns it in Unicode normal form
+// C, so names that look the same are the same name however the source
+// spelled them.
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for unicode.IsLetter(l.char) {
+	for isIdentifierChar(l.char) {
 		l.readChar()
 	}
-	return l.input[position:l.position]
+	return norm.NFC.String(l.input[position:l.position])
 }
 
+func isDigit(ch rune) bool {
+	return '0' <= ch && ch <= '9'
+}
+
+// readNumber reads an integer literal: decimal, or hex, octal or binary
+// with a 0x, 0o or 0b prefix, with underscores allowed between digits. Any
+// letters or digits running on from the literal are read as part of it, so
+// that 12abc is reported as one bad number rather than two tokens.
 func (l *Lexer) readNumber() string {
 	position := l.position
-	for unicode.IsDigit(l.char) {
+	if l.char == '0' && strings.ContainsRune("xXoObB", l.peekChar()) {
+		l.readChar()
+		l.readChar()
+	}
+	for isIdentifierChar(l.char) {
 		l.readChar()
 	}
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) readString() string {
-	//position := l.position + 1
-	result := ""
-	for {
-		l.readChar()
-		if l.char == '"' || l.char == 0 {
-			break
-		}
-		if l.char == '\\' {
-			switch l.peekChar() {
-			case 't':
-				result += "\t"
-			case 'n':
-				result += "\n"
-			case 'r':
-				result += "\r"
-			case '\\':
-				result += "\\"
-			case '"':
-				result += "\""
-			default:
-				result += l.input[l.position : l.position+2]
-			}
-			l.readChar()
-		} else {
-			result += string(l.char)
+// checkNumber returns what is wrong with the integer literal lit, or ""
+// if it is well formed. A leading 0 without a prefix makes the literal
+// octal, as in 007.
+func checkNumber(lit string) string {
+	base, name, digits := 10, "decimal", lit
+	switch {
+	case len(lit) > 1 && strings.ContainsRune("xX", rune(lit[1])):
+		base, name, digits = 16, "hexadecimal", lit[2:]
+	case len(lit) > 1 && strings.ContainsRune("oO", rune(lit[1])):
+		base, name, digits = 8, "octal", lit[2:]
+	case len(lit) > 1 && strings.ContainsRune("bB", rune(lit[1])):
+		base, name, digits = 2, "binary", lit[2:]
+	case len(lit) > 1 && lit[0] == '0':
+		base, name, digits = 8, "octal", lit[1:]
+	}
+	if base != 10 {
+		// Like Go, allow an underscore straight after the prefix.
+		digits = strings.TrimPrefix(digits, "_")
+	}
+	if digits == "" {
+		return "no digits after " + lit
+	}
+	previous := '_'
+	for _, ch := range digits {
+		switch {
+		case ch == '_' && previous == '_':
+			return "_ must separate successive digits"
+		case ch != '_' && digitValue(ch) >= base:
+			return fmt.Sprintf("invalid digit %q in %s literal", ch, name)
 		}
+		previous = ch
+	}
+	if previous == '_' {
+		return "_ must separate successive digits"
+	}
+	return ""
+}
+
+// digitValue returns the value of the ASCII hex digit ch, or 16 if ch is
+// not one.
+func digitValue(ch rune) int {
+	switch {
+	case isDigit(ch):
+		return int(ch - '0')
+	case 'a' <= ch && ch <= 'f':
+		return int(ch - 'a' + 10)
+	case 'A' <= ch && ch <= 'F':
+		return int(ch - 'A' + 10)
 	}
-	return result
+	return 16
 }
 
 func (l *Lexer) NextToken() token.Token {
 	var t token.Token
 
 	l.skipWhitespace()
+	l.discard()
 
 	switch l.char {
 	case '=':
 		if l.peekChar() == '=' {
-			ch := l.char
+			t = l.newToken(token.EQ, l.char)
 			l.readChar()
-			literal := string(ch) + string(l.char)
-			t = token.Token{Type: token.EQ, Literal: literal, Line: l.line, Row: l.row}
+			t.Literal += string(l.char)
+		} else if l.peekChar() == '>' {
+			t = l.newToken(token.ARROW, l.char)
+			l.readChar()
+			t.Literal += string(l.char)
 		} else {
 			t = l.newToken(token.ASSIGN, l.char)
 		}
@@ -140,13 +334,21 @@ func (l *Lexer) NextToken() token.Token {
 		t = l.newToken(token.DIV, l.char)
 	case '!':
 		if l.peekChar() == '=' {
-			ch := l.char
+			t = l.newToken(token.NOT_EQ, l.char)
 			l.readChar()
-			literal := string(ch) + string(l.char)
-			t = token.Token{Type: token.NOT_EQ, Literal: literal, Line: l.line, Row: l.row}
+			t.Literal += string(l.char)
 		} else {
 			t = l.newToken(token.BANG, l.char)
 		}
+	case '|':
+		if l.peekChar() == '>' {
+			t = l.newToken(token.PIPE, l.char)
+			l.readChar()
+			t.Literal += string(l.char)
+		} else {
+			t = l.newToken(token.ILLEGAL, l.char)
+			l.errorf(t, "unexpected character %q", l.char)
+		}
 	case '%':
 		t = l.newToken(token.MOD, l.char)
 	case '<':
@@ -163,30 +365,63 @@ func (l *Lexer) NextToken() token.Token {
 		t = l.newToken(token.RPAREN, l.char)
 	case '{':
 		t = l.newToken(token.LBRACE, l.char)
+		if n := len(l.templates); n > 0 {
+			l.templates[n-1]++
+		}
 	case '}':
 		t = l.newToken(token.RBRACE, l.char)
+		if n := len(l.templates); n > 0 {
+			if l.templates[n-1] == 0 {
+				l.templates = l.templates[:n-1]
+				t = l.readStringToken(t, token.TEMPLATE_END)
+			} else {
+				l.templates[n-1]--
+			}
+		}
 	case '[':
 		t = l.newToken(token.LBRACKET, l.char)
 	case ']':
 		t = l.newToken(token.RBRACKET, l.char)
 	case ':':
 		t = l.newToken(token.COLON, l.char)
+	case '?':
+		t = l.newToken(token.QUESTION, l.char)
+	case '.':
+		if l.peekChar() == '.' && l.peekCharN(2) == '.' {
+			t = l.newToken(token.ELLIPSIS, l.char)
+			t.Literal = "..."
+			l.readChar()
+			l.readChar()
+		} else {
+			t = l.newToken(token.DOT, l.char)
+		}
 	case '"':
-		t.Type = token.STRING
-		t.Literal = l.readString()
+		if l.peekChar() == '"' && l.peekCharN(2) == '"' {
+			t = l.readTripleQuotedString(l.newToken(token.STRING, l.char))
+		} else {
+			t = l.readStringToken(l.newToken(token.STRING, l.char), token.STRING)
+		}
+	case '`':
+		t = l.readRawString(l.newToken(token.STRING, l.char))
 	case 0:
 		t = l.newToken(token.EOF, 0)
 	default:
-		if unicode.IsLetter(l.char) {
+		t = l.here()
+		if isLetter(l.char) {
 			t.Literal = l.readIdentifier()
 			t.Type = token.LookupIdent(t.Literal)
 			return t
-		} else if unicode.IsDigit(l.char) {
+		} else if isDigit(l.char) {
 			t.Type = token.INT
 			t.Literal = l.readNumber()
+			if msg := checkNumber(t.Literal); msg != "" {
+				l.errorf(t, "invalid number %q: %s", t.Literal, msg)
+				t.Type = token.ILLEGAL
+			}
 			return t
 		} else {
 			t = l.newToken(token.ILLEGAL, l.char)
+			l.errorf(t, "unexpected character %q", l.char)
 		}
 	}
 	l.readChar()
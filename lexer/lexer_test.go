@@ -2,7 +2,9 @@ package lexer
 
 import (
 	"learn-interpreter/token"
+	"strings"
 	"testing"
+	"testing/iotest"
 )
 
 func TestNextToken(t *testing.T) {
@@ -12,7 +14,7 @@ func TestNextToken(t *testing.T) {
   		x + y; 
     }; 
  	let result = add(five, ten); 
-    !-/*5; 
+    !-/ *5; 
     5 < 10 > 5; 
 	if (5 < 10) { 
         return true; 
@@ -158,10 +160,364 @@ func TestNextToken(t *testing.T) {
 	for i, tt := range tests {
 		tk := l.NextToken()
 		if tk.Type != tt.expectedType {
-			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q, line=%v, row=%v", i, tt.expectedType, tk.Type, tk.Line, tk.Row)
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q, line=%v, column=%v", i, tt.expectedType, tk.Type, tk.Line, tk.Column)
 		}
 		if tk.Literal != tt.expectedLiteral {
 			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tk.Literal)
 		}
 	}
 }
+
+func TestEllipsisAndUnderscoreIdentifiers(t *testing.T) {
+	input := `macro(first, ...rest_args) { unquote_splice(rest_args) } _ .`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.MACRO, "macro"},
+		{token.LPAREN, "("},
+		{token.IDENT, "first"},
+		{token.COMMA, ","},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "rest_args"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "unquote_splice"},
+		{token.LPAREN, "("},
+		{token.IDENT, "rest_args"},
+		{token.RPAREN, ")"},
+		{token.RBRACE, "}"},
+		{token.IDENT, "_"},
+		{token.DOT, "."},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tk := l.NextToken()
+		if tk.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tk.Type)
+		}
+		if tk.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tk.Literal)
+		}
+	}
+}
+
+func TestInterpolatedStrings(t *testing.T) {
+	input := `"Hello ${name}, ${len({"a": "${x}"})} items\${}" "plain"`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.TEMPLATE_PART, "Hello "},
+		{token.IDENT, "name"},
+		{token.TEMPLATE_PART, ", "},
+		{token.IDENT, "len"},
+		{token.LPAREN, "("},
+		{token.LBRACE, "{"},
+		{token.STRING, "a"},
+		{token.COLON, ":"},
+		{token.TEMPLATE_PART, ""},
+		{token.IDENT, "x"},
+		{token.TEMPLATE_END, ""},
+		{token.RBRACE, "}"},
+		{token.RPAREN, ")"},
+		{token.TEMPLATE_END, " items${}"},
+		{token.STRING, "plain"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tk := l.NextToken()
+		if tk.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tk.Type)
+		}
+		if tk.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tk.Literal)
+		}
+	}
+}
+
+func TestStringLiterals(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{`"caf\u{e9} \u{1F600}"`, token.STRING, "café 😀"},
+		{`"\x41\x7a"`, token.STRING, "Az"},
+		{`"tab\there \$ \` + "`" + `"`, token.STRING, "tab\there $ `"},
+		{"`raw \\n ${x}\nsecond line`", token.STRING, "raw \\n ${x}\nsecond line"},
+		{"\"\"\"\n    first\n      indented\n\n    last \\u{21}\n    \"\"\"", token.STRING, "first\n  indented\n\nlast !"},
+		{`"""one "quoted" line"""`, token.STRING, `one "quoted" line`},
+		{`""`, token.STRING, ""},
+		{`"bad \q escape"`, token.ILLEGAL, "bad  escape"},
+		{`"unterminated`, token.ILLEGAL, "unterminated"},
+		{"`unterminated", token.ILLEGAL, "unterminated"},
+		{`"""unterminated`, token.ILLEGAL, "unterminated"},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		tk := l.NextToken()
+		if tk.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tk.Type)
+		}
+		if tk.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tk.Literal)
+		}
+		if next := l.NextToken(); next.Type != token.EOF {
+			t.Fatalf("tests[%d] - expected EOF after string, got=%q", i, next.Type)
+		}
+	}
+}
+
+func TestLexerErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`"a\qb"`, []string{`1:3: invalid escape \q`}},
+		{`"\xZZ"`, []string{`1:2: invalid \x escape`}},
+		{`"\xA"`, []string{`1:2: invalid \x escape`}},
+		{`"\x" + 1`, []string{`1:2: invalid \x escape`}},
+		{`"\u{110000}"`, []string{`1:2: invalid escape \u{110000}: not a valid code point`}},
+		{`"\u41"`, []string{`1:2: invalid escape \u: want \u{hex}`}},
+		{"let s = \"abc\n", []string{"1:9: unterminated string literal"}},
+		{"x\n  `abc", []string{"2:3: unterminated raw string literal"}},
+		{"`a\nb` @", []string{"2:4: unexpected character '@'"}},
+		{"a | b", []string{"1:3: unexpected character '|'"}},
+		{"x /* never\nclosed *", []string{"1:3: unterminated comment"}},
+		{"0x + 0b102 + 0o8", []string{
+			`1:1: invalid number "0x": no digits after 0x`,
+			`1:6: invalid number "0b102": invalid digit '2' in binary literal`,
+			`1:14: invalid number "0o8": invalid digit '8' in octal literal`,
+		}},
+		{"1__0 1_ 08 12abc", []string{
+			`1:1: invalid number "1__0": _ must separate successive digits`,
+			`1:6: invalid number "1_": _ must separate successive digits`,
+			`1:9: invalid number "08": invalid digit '8' in octal literal`,
+			`1:12: invalid number "12abc": invalid digit 'a' in decimal literal`,
+		}},
+		{"let a = @;\nlet b = \"ok\" # 1;\nlet c = \"open", []string{
+			"1:9: unexpected character '@'",
+			"2:14: unexpected character '#'",
+			"3:9: unterminated string literal",
+		}},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		for tk := l.NextToken(); tk.Type != token.EOF; tk = l.NextToken() {
+		}
+		errors := l.Errors()
+		if len(errors) != len(tt.expected) {
+			t.Fatalf("tests[%d] - wrong number of errors. expected=%q, got=%q", i, tt.expected, errors)
+		}
+		for j, msg := range tt.expected {
+			if errors[j] != msg {
+				t.Errorf("tests[%d] - error wrong. expected=%q, got=%q", i, msg, errors[j])
+			}
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `a // to the end of the line / *
+/* across
+   lines // */ b / c /**/ d`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.IDENT, "b"},
+		{token.DIV, "/"},
+		{token.IDENT, "c"},
+		{token.IDENT, "d"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tk := l.NextToken()
+		if tk.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tk.Type)
+		}
+		if tk.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tk.Literal)
+		}
+	}
+}
+
+func TestCommentForms(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"// only a comment", []string{}},
+		{"/* only a comment */", []string{}},
+		{"x // no newline at the end", []string{"x"}},
+		{"x // first\n// second\ny", []string{"x", "y"}},
+		{"x /**/y", []string{"x", "y"}},
+		{"x /* a\n * b\n */ y", []string{"x", "y"}},
+		{"x /* // */ y", []string{"x", "y"}},
+		{"x // /* \ny */", []string{"x", "y", "*", "/"}},
+		{"x /* a /* b */ y", []string{"x", "y"}},
+		{"x /*/ y */ z", []string{"x", "z"}},
+		{"a/b", []string{"a", "/", "b"}},
+		{`"// not a comment" "/* nor this */"`, []string{"// not a comment", "/* nor this */"}},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		literals := []string{}
+		for tk := l.NextToken(); tk.Type != token.EOF; tk = l.NextToken() {
+			literals = append(literals, tk.Literal)
+		}
+		if len(l.Errors()) != 0 {
+			t.Errorf("tests[%d] - unexpected errors: %v", i, l.Errors())
+		}
+		if len(literals) != len(tt.expected) {
+			t.Errorf("tests[%d] - tokens wrong. expected=%q, got=%q", i, tt.expected, literals)
+			continue
+		}
+		for j, literal := range tt.expected {
+			if literals[j] != literal {
+				t.Errorf("tests[%d] - tokens wrong. expected=%q, got=%q", i, tt.expected, literals)
+				break
+			}
+		}
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	input := `1_000 0xFF_ff 0x_1 0o17 0b1010 007 12abc ٣`
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "1_000"},
+		{token.INT, "0xFF_ff"},
+		{token.INT, "0x_1"},
+		{token.INT, "0o17"},
+		{token.INT, "0b1010"},
+		{token.INT, "007"},
+		{token.ILLEGAL, "12abc"},
+		{token.ILLEGAL, "٣"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tk := l.NextToken()
+		if tk.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tk.Type)
+		}
+		if tk.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tk.Literal)
+		}
+	}
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	// The second café is spelled with a combining acute accent.
+	input := "let größe = caf\u00e9 + cafe\u0301; π2 名前 x٣ नमस्ते _x1 Ωmega_ω ٣x"
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.LET, "let"},
+		{token.IDENT, "größe"},
+		{token.ASSIGN, "="},
+		{token.IDENT, "café"},
+		{token.PLUS, "+"},
+		{token.IDENT, "café"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "π2"},
+		{token.IDENT, "名前"},
+		{token.IDENT, "x٣"},
+		{token.IDENT, "नमस्ते"},
+		{token.IDENT, "_x1"},
+		{token.IDENT, "Ωmega_ω"},
+		{token.ILLEGAL, "٣"},
+		{token.IDENT, "x"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tk := l.NextToken()
+		if tk.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q", i, tt.expectedType, tk.Type)
+		}
+		if tk.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tk.Literal)
+		}
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := "let π = 1;\n\"a\nb\" + x\n\t=> y"
+	tests := []struct {
+		expectedLiteral string
+		line            int
+		column          int
+		offset          int
+	}{
+		{"let", 1, 1, 0},
+		{"π", 1, 5, 4},
+		{"=", 1, 7, 7},
+		{"1", 1, 9, 9},
+		{";", 1, 10, 10},
+		{"a\nb", 2, 1, 12},
+		{"+", 3, 4, 18},
+		{"x", 3, 6, 20},
+		{"=>", 4, 2, 23},
+		{"y", 4, 5, 26},
+		{"", 4, 6, 27},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tk := l.NextToken()
+		if tk.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q", i, tt.expectedLiteral, tk.Literal)
+		}
+		if tk.Line != tt.line || tk.Column != tt.column || tk.Offset != tt.offset {
+			t.Errorf("tests[%d] - position of %q wrong. expected=%d:%d+%d, got=%d:%d+%d", i,
+				tk.Literal, tt.line, tt.column, tt.offset, tk.Line, tk.Column, tk.Offset)
+		}
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	input := "let größe = \"\\u{1F600} ${x}\";\n`raw` \"\"\"\n  doc\n  \"\"\" 0xFF @"
+	expected := New(input).Tokens()
+
+	l := NewReader(iotest.OneByteReader(strings.NewReader(input)), "main.es")
+	tokens := l.Tokens()
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("wrong number of tokens. expected=%d, got=%d", len(expected), len(tokens))
+	}
+	for i, tk := range tokens {
+		want := expected[i]
+		want.File = "main.es"
+		if tk != want {
+			t.Errorf("tokens[%d] wrong. expected=%+v, got=%+v", i, want, tk)
+		}
+	}
+	errors := l.Errors()
+	if len(errors) != 1 || errors[0] != "main.es:4:12: unexpected character '@'" {
+		t.Errorf("wrong errors. got=%q", errors)
+	}
+}
@@ -0,0 +1,212 @@
+package lexer
+
+import (
+	"learn-interpreter/token"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// readStringToken reads the rest of a string literal, or of an
+// interpolated string after the } closing an interpolation. Strings that
+// are unterminated or contain invalid escapes are returned as ILLEGAL.
+func (l *Lexer) readStringToken(t token.Token, plain token.TokenType) token.Token {
+	errors := len(l.errors)
+	literal, interpolated := l.readString()
+	t.Literal = literal
+	t.Type = plain
+	switch {
+	case l.char == 0:
+		l.errorf(t, "unterminated string literal")
+		t.Type = token.ILLEGAL
+	case len(l.errors) > errors:
+		t.Type = token.ILLEGAL
+	case interpolated:
+		t.Type = token.TEMPLATE_PART
+		l.templates = append(l.templates, 0)
+	}
+	return t
+}
+
+// readString reads string characters up to the closing quote, or up to
+// the ${ starting an interpolation, in which case it reports true.
+func (l *Lexer) readString() (string, bool) {
+	var out strings.Builder
+	for {
+		l.readChar()
+		switch {
+		case l.char == '"' || l.char == 0:
+			return out.String(), false
+		case l.char == '$' && l.peekChar() == '{':
+			l.readChar()
+			return out.String(), true
+		case l.char == '\\':
+			at := l.here()
+			l.fill(maxEscapeSize)
+			decoded, size, msg := decodeEscape(l.input[l.readPosition:])
+			if msg != "" {
+				l.errorf(at, "%s", msg)
+			}
+			out.WriteString(decoded)
+			for end := l.readPosition + size; l.readPosition < end; {
+				l.readChar()
+			}
+		default:
+			out.WriteRune(l.char)
+		}
+	}
+}
+
+// readRawString reads a backtick string, which may span several lines and
+// has no escapes or interpolation.
+func (l *Lexer) readRawString(t token.Token) token.Token {
+	var out strings.Builder
+	for {
+		l.readChar()
+		if l.char == '`' {
+			break
+		}
+		if l.char == 0 {
+			l.errorf(t, "unterminated raw string literal")
+			t.Type = token.ILLEGAL
+			break
+		}
+		out.WriteRune(l.char)
+	}
+	t.Literal = out.String()
+	return t
+}
+
+// readTripleQuotedString reads a """ string. Its text is dedented as
+// described at dedent before escapes are decoded; it has no interpolation.
+func (l *Lexer) readTripleQuotedString(t token.Token) token.Token {
+	l.readChar()
+	l.readChar()
+	start := l.readPosition
+	for {
+		l.readChar()
+		if l.char == 0 {
+			l.errorf(t, "unterminated string literal")
+			t.Type = token.ILLEGAL
+			t.Literal = l.input[start:]
+			return t
+		}
+		if l.char == '\\' {
+			l.readChar()
+		} else if l.char == '"' && l.peekChar() == '"' && l.peekCharN(2) == '"' {
+			break
+		}
+	}
+	raw := dedent(l.input[start:l.position])
+	l.readChar()
+	l.readChar()
+
+	var out strings.Builder
+	for i := 0; i < len(raw); {
+		if raw[i] != '\\' {
+			r, w := utf8.DecodeRuneInString(raw[i:])
+			out.WriteRune(r)
+			i += w
+			continue
+		}
+		decoded, size, msg := decodeEscape(raw[i+1:])
+		if msg != "" {
+			l.errorf(t, "%s", msg)
+			t.Type = token.ILLEGAL
+		}
+		out.WriteString(decoded)
+		i += 1 + size
+	}
+	t.Literal = out.String()
+	return t
+}
+
+// maxEscapeSize is the longest escape sequence after its backslash,
+// \u{10FFFF}.
+const maxEscapeSize = 10
+
+// decodeEscape decodes the escape sequence at the start of s, which
+// follows a backslash. It returns the decoded text, how many bytes of s it
+// used and, for an invalid escape, an error message.
+//
+// Besides \t \n \r \\ \" \$ and \` it understands \xNN, the character
+// with code point NN, and \u{N...}, the character with code point N...,
+// both in hex.
+func decodeEscape(s string) (string, int, string) {
+	if s == "" {
+		return "", 0, "unterminated escape sequence"
+	}
+	switch s[0] {
+	case 't':
+		return "\t", 1, ""
+	case 'n':
+		return "\n", 1, ""
+	case 'r':
+		return "\r", 1, ""
+	case '\\', '"', '$', '`':
+		return s[:1], 1, ""
+	case 'x':
+		// Only hex digits are consumed, so that a short escape does not
+		// swallow the closing quote.
+		digits := 0
+		for digits < 2 && 1+digits < len(s) && digitValue(rune(s[1+digits])) < 16 {
+			digits++
+		}
+		if digits < 2 {
+			return "", 1 + digits, `invalid \x escape`
+		}
+		n, _ := strconv.ParseUint(s[1:3], 16, 8)
+		return string(rune(n)), 3, ""
+	case 'u':
+		end := strings.IndexByte(s[:min(len(s), maxEscapeSize)], '}')
+		if len(s) < 2 || s[1] != '{' || end < 0 {
+			return "", 1, `invalid escape \u: want \u{hex}`
+		}
+		digits := s[2:end]
+		n, err := strconv.ParseUint(digits, 16, 32)
+		if err != nil || len(digits) > 6 || !utf8.ValidRune(rune(n)) {
+			return "", end + 1, `invalid escape \u{` + digits + "}: not a valid code point"
+		}
+		return string(rune(n)), end + 1, ""
+	default:
+		r, w := utf8.DecodeRuneInString(s)
+		return "", w, "invalid escape \\" + string(r)
+	}
+}
+
+// dedent prepares the text of a """ string: a first line holding nothing
+// but whitespace is dropped, as is a last line holding only the
+// indentation of the closing quotes, and then the indentation common to
+// all non-blank lines is removed.
+func dedent(s string) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) > 1 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	if last := len(lines) - 1; last > 0 && strings.TrimSpace(lines[last]) == "" {
+		lines = lines[:last]
+	}
+	indent := ""
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lead := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			indent, first = lead, false
+			continue
+		}
+		for !strings.HasPrefix(lead, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+		} else {
+			lines[i] = strings.TrimPrefix(line, indent)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
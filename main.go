@@ -1,13 +1,34 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"learn-interpreter/eval"
+	"learn-interpreter/lexer"
 	"learn-interpreter/repl"
 	"os"
 	"os/user"
+	"path/filepath"
+)
+
+var (
+	traceMacros = flag.Bool("trace-macros", false, "log every macro expansion step to stderr")
+	modulePath  = flag.String("module-path", os.Getenv("ESLANG_PATH"),
+		"list of directories searched for imported modules")
 )
 
 func main() {
+	flag.Parse()
+	modules := eval.NewModuleLoader()
+	if *modulePath != "" {
+		modules.SearchPath = filepath.SplitList(*modulePath)
+	}
+	if flag.Arg(0) == "tokens" {
+		os.Exit(dumpTokens(flag.Arg(1)))
+	}
+	if flag.NArg() > 0 {
+		os.Exit(runFile(flag.Arg(0), modules))
+	}
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
@@ -15,5 +36,49 @@ func main() {
 	fmt.Printf("Hello %s! This is the eslang programming language!\n",
 		user.Username)
 	fmt.Printf("Feel free to type in commands\n")
-	repl.Start(os.Stdin, os.Stdout)
+	repl.Start(os.Stdin, os.Stdout, modules)
+}
+
+func runFile(path string, modules *eval.ModuleLoader) int {
+	input, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	expander := eval.NewMacroExpander()
+	expander.File = path
+	expander.Modules = modules
+	if *traceMacros {
+		expander.Trace = os.Stderr
+	}
+	if !repl.Run(string(input), os.Stderr, expander) {
+		return 1
+	}
+	return 0
+}
+
+// dumpTokens prints the tokens of the file at path, or of standard input
+// if path is empty, one per line with their position and byte offset.
+func dumpTokens(path string) int {
+	in, name := os.Stdin, "<stdin>"
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		in, name = f, path
+	}
+	l := lexer.NewReader(in, name)
+	for _, t := range l.Tokens() {
+		fmt.Printf("%s\t%d\t%s\t%q\n", t.Position(), t.Offset, t.Type, t.Literal)
+	}
+	for _, msg := range l.Errors() {
+		fmt.Fprintln(os.Stderr, msg)
+	}
+	if len(l.Errors()) != 0 {
+		return 1
+	}
+	return 0
 }
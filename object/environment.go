@@ -12,8 +12,51 @@ func NewEnvironment() *Environment {
 }
 
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store     map[string]Object
+	constants map[string]bool
+	outer     *Environment
+
+	file     string
+	importer Importer
+	macros   *Environment
+}
+
+// SetMacros records the environment holding the macros that the code
+// evaluated in e was expanded with. Environments enclosed in e share it.
+func (e *Environment) SetMacros(macros *Environment) {
+	e.macros = macros
+}
+
+// Macros returns the environment recorded by SetMacros on e or on the
+// nearest environment it is enclosed in, or nil if none was recorded.
+func (e *Environment) Macros() *Environment {
+	if e.macros == nil && e.outer != nil {
+		return e.outer.Macros()
+	}
+	return e.macros
+}
+
+// Importer loads the module imported as path by code in the file importer,
+// which is empty for code that does not come from a file.
+type Importer interface {
+	Import(path string, importer string) (*Module, error)
+}
+
+// SetImporter records the file whose code is evaluated in e and the
+// Importer used by its import statements. Environments enclosed in e share
+// both.
+func (e *Environment) SetImporter(file string, importer Importer) {
+	e.file = file
+	e.importer = importer
+}
+
+// Importer returns the file and Importer recorded on e or on the nearest
+// environment it is enclosed in. The Importer is nil if none was recorded.
+func (e *Environment) Importer() (string, Importer) {
+	if e.importer == nil && e.outer != nil {
+		return e.outer.Importer()
+	}
+	return e.file, e.importer
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
@@ -24,7 +67,47 @@ func (e *Environment) Get(name string) (Object, bool) {
 	return obj, ok
 }
 
+// Assign replaces the value of name in the innermost environment that
+// defines it. It reports false if name is not defined.
+func (e *Environment) Assign(name string, val Object) bool {
+	if _, ok := e.store[name]; ok {
+		e.store[name] = val
+		return true
+	}
+	if e.outer != nil {
+		return e.outer.Assign(name, val)
+	}
+	return false
+}
+
 func (e *Environment) Set(name string, val Object) Object {
 	e.store[name] = val
 	return val
 }
+
+// SetConst binds name to val like Set does, and marks it as a constant.
+func (e *Environment) SetConst(name string, val Object) Object {
+	if e.constants == nil {
+		e.constants = make(map[string]bool)
+	}
+	e.constants[name] = true
+	return e.Set(name, val)
+}
+
+// Declared reports whether name is defined in e itself, leaving out the
+// environments it is enclosed in, and whether it is a constant there.
+func (e *Environment) Declared(name string) (defined, constant bool) {
+	_, defined = e.store[name]
+	return defined, e.constants[name]
+}
+
+// IsConst reports whether the innermost definition of name is a constant.
+func (e *Environment) IsConst(name string) bool {
+	if _, ok := e.store[name]; ok {
+		return e.constants[name]
+	}
+	if e.outer != nil {
+		return e.outer.IsConst(name)
+	}
+	return false
+}
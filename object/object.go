@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"hash/fnv"
 	"learn-interpreter/ast"
+	"math/big"
 	"strings"
 )
 
@@ -12,6 +13,7 @@ type ObjectType string
 
 const (
 	OBJ_TYPE_INTEGER      = "Integer"
+	OBJ_TYPE_BIG_INTEGER  = "BigInt"
 	OBJ_TYPE_BOOLEAN      = "Boolean"
 	OBJ_TYPE_NULL         = "Null"
 	OBJ_TYPE_RETURN_VALUE = "ReturnValue"
@@ -23,6 +25,10 @@ const (
 	OBJ_TYPE_HASH         = "Hash"
 	OBJ_TYPE_QUOTE        = "Quote"
 	OBJ_TYPE_MACRO        = "Macro"
+	OBJ_TYPE_NATIVE_MACRO = "NativeMacro"
+	OBJ_TYPE_MODULE       = "Module"
+	OBJ_TYPE_STRUCT_TYPE  = "StructType"
+	OBJ_TYPE_STRUCT       = "Struct"
 )
 
 type HashKey struct {
@@ -53,6 +59,28 @@ func (i *Integer) HashKey() HashKey {
 	return i.hashKey
 }
 
+// BigInt holds integers outside the range of Integer. Integer arithmetic
+// that overflows produces a BigInt, and BigInt results that fit are turned
+// back into Integers, so each integer value has a single representation.
+type BigInt struct {
+	Value   *big.Int
+	hashKey HashKey
+}
+
+func (bi *BigInt) Inspect() string  { return bi.Value.String() }
+func (bi *BigInt) Type() ObjectType { return OBJ_TYPE_BIG_INTEGER }
+func (bi *BigInt) HashKey() HashKey {
+	if bi.hashKey.Value == 0 && bi.hashKey.Type == "" {
+		h := fnv.New64a()
+		if bi.Value.Sign() < 0 {
+			h.Write([]byte{'-'})
+		}
+		h.Write(bi.Value.Bytes())
+		bi.hashKey = HashKey{Type: bi.Type(), Value: h.Sum64()}
+	}
+	return bi.hashKey
+}
+
 type Boolean struct {
 	Value   bool
 	hashKey HashKey
@@ -85,15 +113,28 @@ type ReturnValue struct {
 func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 func (rv *ReturnValue) Type() ObjectType { return OBJ_TYPE_RETURN_VALUE }
 
+const (
+	ERROR_KIND_RUNTIME = "RuntimeError"
+	ERROR_KIND_THROWN  = "Error"
+)
+
+// Error aborts evaluation until it is caught by a try expression. Line and
+// Column are those of the innermost expression that failed; Column is zero
+// while the position is unknown. Value is the operand of throw, if any.
 type Error struct {
 	Message string
+	Kind    string
+	Line    int
+	Column  int
+	Value   Object
 }
 
 func (e *Error) Type() ObjectType { return OBJ_TYPE_ERROR }
 func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
 
 type Function struct {
-	Parameters []*ast.Identifier
+	Parameters []ast.Expression
+	Rest       *ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
 }
@@ -105,6 +146,9 @@ func (f *Function) Inspect() string {
 	for _, p := range f.Parameters {
 		params = append(params, p.String())
 	}
+	if f.Rest != nil {
+		params = append(params, "..."+f.Rest.String())
+	}
 	out.WriteString("fn")
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
@@ -139,8 +183,11 @@ type Builtin struct {
 func (b *Builtin) Type() ObjectType { return OBJ_TYPE_BUILTIN }
 func (b *Builtin) Inspect() string  { return "builtin function" }
 
+// Array is a list of values. A frozen array, and every array and hash
+// inside it, cannot be modified.
 type Array struct {
 	Elements []Object
+	Frozen   bool
 }
 
 func (ao *Array) Type() ObjectType { return OBJ_TYPE_ARRAY }
@@ -161,8 +208,10 @@ type HashPair struct {
 	Value Object
 }
 
+// Hash maps keys to values. Like an array, it can be frozen.
 type Hash struct {
-	Pairs map[HashKey]HashPair
+	Pairs  map[HashKey]HashPair
+	Frozen bool
 }
 
 func (h *Hash) Type() ObjectType { return OBJ_TYPE_HASH }
@@ -188,6 +237,7 @@ func (q *Quote) Inspect() string  { return "QUOTE(" + q.Node.String() + ")" }
 
 type Macro struct {
 	Parameters []*ast.Identifier
+	Rest       *ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
 }
@@ -199,6 +249,9 @@ func (m *Macro) Inspect() string {
 	for _, p := range m.Parameters {
 		params = append(params, p.String())
 	}
+	if m.Rest != nil {
+		params = append(params, "..."+m.Rest.String())
+	}
 	out.WriteString("fn")
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
@@ -207,3 +260,73 @@ func (m *Macro) Inspect() string {
 	out.WriteString("\n}")
 	return out.String()
 }
+
+// NativeMacroFunction receives the unevaluated arguments of a macro call and
+// returns the node the call expands to.
+type NativeMacroFunction func(args []ast.Expression) (ast.Node, error)
+
+type NativeMacro struct {
+	Name string
+	Fn   NativeMacroFunction
+}
+
+func (nm *NativeMacro) Type() ObjectType { return OBJ_TYPE_NATIVE_MACRO }
+func (nm *NativeMacro) Inspect() string  { return "native macro " + nm.Name }
+
+// Module holds the exported bindings of an imported file. Macros are kept
+// apart from Members because they are only used during macro expansion.
+type Module struct {
+	Name    string
+	Path    string
+	Members map[string]Object
+	Macros  map[string]Object
+}
+
+func (m *Module) Type() ObjectType { return OBJ_TYPE_MODULE }
+func (m *Module) Inspect() string  { return "module " + m.Name }
+
+// StructType is the value bound by a struct declaration. Calling it with
+// one argument per field constructs a Struct.
+type StructType struct {
+	Name   string
+	Fields []string
+	// Module is the name of the module that declares the type at its top
+	// level. It is set once the module has been loaded, and is empty for
+	// other types.
+	Module string
+}
+
+func (st *StructType) Type() ObjectType { return OBJ_TYPE_STRUCT_TYPE }
+func (st *StructType) Inspect() string {
+	return "struct " + st.Name + " { " + strings.Join(st.Fields, ", ") + " }"
+}
+
+// HasField reports whether name is one of the declared fields.
+func (st *StructType) HasField(name string) bool {
+	for _, field := range st.Fields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+type Struct struct {
+	StructType *StructType
+	Fields     map[string]Object
+	Frozen     bool
+}
+
+func (s *Struct) Type() ObjectType { return OBJ_TYPE_STRUCT }
+func (s *Struct) Inspect() string {
+	var out bytes.Buffer
+	fields := []string{}
+	for _, name := range s.StructType.Fields {
+		fields = append(fields, fmt.Sprintf("%s: %s", name, s.Fields[name].Inspect()))
+	}
+	out.WriteString(s.StructType.Name)
+	out.WriteString("{")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString("}")
+	return out.String()
+}
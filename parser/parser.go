@@ -1,16 +1,21 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"learn-interpreter/ast"
 	"learn-interpreter/lexer"
 	"learn-interpreter/token"
+	"math/big"
 	"strconv"
 )
 
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN      // =
+	TERNARY     // ? :
+	PIPE        // |>
 	EQUALS      // ==
 	LESSGREATER // > or <
 	SUM         // +
@@ -21,6 +26,9 @@ const (
 )
 
 var precedences = map[token.TokenType]int{
+	token.ASSIGN:   ASSIGN,
+	token.QUESTION: TERNARY,
+	token.PIPE:     PIPE,
 	token.EQ:       EQUALS,
 	token.NOT_EQ:   EQUALS,
 	token.LT:       LESSGREATER,
@@ -31,6 +39,7 @@ var precedences = map[token.TokenType]int{
 	token.MULTI:    PRODUCT,
 	token.LPAREN:   CALL,
 	token.LBRACKET: INDEX,
+	token.DOT:      INDEX,
 }
 
 type Parser struct {
@@ -42,6 +51,11 @@ type Parser struct {
 
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
+
+	// guard is set while a match guard is parsed, where the => after an
+	// identifier or a parenthesised expression ends the guard instead of
+	// starting an arrow function.
+	guard bool
 }
 
 type (
@@ -61,13 +75,19 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
+	p.registerPrefix(token.NULL, p.parseNull)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionExpression)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
+	p.registerPrefix(token.TEMPLATE_PART, p.parseInterpolatedString)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 	p.registerPrefix(token.MACRO, p.parseMacroLiteral)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
+	p.registerPrefix(token.TRY, p.parseTryExpression)
+	p.registerPrefix(token.ELLIPSIS, p.parseSpreadExpression)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
@@ -80,6 +100,10 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.GT, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PIPE, p.parsePipeExpression)
+	p.registerInfix(token.QUESTION, p.parseConditionalExpression)
 
 	p.nextToken()
 	p.nextToken()
@@ -92,11 +116,19 @@ func (p *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFn) {
 func (p *Parser) registerInfix(tokenType token.TokenType, fn infixParseFn) {
 	p.infixParseFns[tokenType] = fn
 }
+
+// Errors lists the problems found by the lexer followed by those found by
+// the parser.
 func (p *Parser) Errors() []string {
-	return p.errors
+	errors := append([]string{}, p.l.Errors()...)
+	return append(errors, p.errors...)
 }
 
 func (p *Parser) peekError(t token.TokenType) {
+	if p.peekTokenIs(token.ILLEGAL) {
+		// The lexer has already reported the bad token.
+		return
+	}
 	msg := fmt.Sprintf("expected next token to be %s, got %s instead",
 		t, p.peekToken.Type)
 	p.errors = append(p.errors, msg)
@@ -155,15 +187,28 @@ func (p *Parser) ParseProgram() *ast.Program {
 }
 
 func (p *Parser) parseIdentifier() ast.Expression {
-	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.peekTokenIs(token.ARROW) && !p.guard {
+		p.nextToken()
+		return p.parseArrowFunction(ident.Token, []ast.Expression{ident})
+	}
+	return ident
 }
 
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
-	case token.LET:
+	case token.LET, token.CONST:
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.IMPORT:
+		return p.parseImportStatement()
+	case token.EXPORT:
+		return p.parseExportStatement()
+	case token.STRUCT:
+		return p.parseStructStatement()
+	case token.THROW:
+		return p.parseThrowStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -171,10 +216,17 @@ func (p *Parser) parseStatement() ast.Statement {
 
 func (p *Parser) parseLetStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{Token: p.curToken}
-	if !p.expectPeek(token.IDENT) {
+	switch {
+	case p.peekTokenIs(token.LBRACKET), p.peekTokenIs(token.LBRACE):
+		p.nextToken()
+		if stmt.Pattern = p.parsePattern(); stmt.Pattern == nil {
+			return nil
+		}
+	case p.expectPeek(token.IDENT):
+		stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	default:
 		return nil
 	}
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
 	}
@@ -186,6 +238,125 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	return stmt
 }
 
+func (p *Parser) parseImportStatement() ast.Statement {
+	stmt := &ast.ImportStatement{Token: p.curToken}
+	if !p.expectPeek(token.STRING) {
+		return nil
+	}
+	stmt.Path = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+	if p.peekTokenIs(token.IDENT) && p.peekToken.Literal == "as" {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Alias = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseExportStatement() ast.Statement {
+	stmt := &ast.ExportStatement{Token: p.curToken}
+	switch {
+	case p.peekTokenIs(token.LET), p.peekTokenIs(token.CONST):
+		p.nextToken()
+		let := p.parseLetStatement()
+		if let == nil {
+			return nil
+		}
+		stmt.Statement = let
+	case p.peekTokenIs(token.STRUCT):
+		p.nextToken()
+		structStmt := p.parseStructStatement()
+		if structStmt == nil {
+			return nil
+		}
+		stmt.Statement = structStmt
+	default:
+		msg := fmt.Sprintf("expected let, const or struct after export, got %s instead", p.peekToken.Type)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	return stmt
+}
+
+func (p *Parser) parseThrowStatement() ast.Statement {
+	stmt := &ast.ThrowStatement{Token: p.curToken}
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseTryExpression() ast.Expression {
+	exp := &ast.TryExpression{Token: p.curToken}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	exp.Block = p.parseBlockStatement()
+	if p.peekTokenIs(token.CATCH) {
+		p.nextToken()
+		if p.peekTokenIs(token.LPAREN) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			exp.Param = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			if !p.expectPeek(token.RPAREN) {
+				return nil
+			}
+		}
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+		exp.Catch = p.parseBlockStatement()
+	}
+	if p.peekTokenIs(token.FINALLY) {
+		p.nextToken()
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+		exp.Finally = p.parseBlockStatement()
+	}
+	if exp.Catch == nil && exp.Finally == nil {
+		p.errors = append(p.errors, "try needs a catch or finally block")
+		return nil
+	}
+	return exp
+}
+
+func (p *Parser) parseStructStatement() ast.Statement {
+	stmt := &ast.StructStatement{Token: p.curToken}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Fields = []*ast.Identifier{}
+	for !p.peekTokenIs(token.RBRACE) {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Fields = append(stmt.Fields, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
 	p.nextToken()
@@ -211,6 +382,12 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		if n, ok := new(big.Int).SetString(p.curToken.Literal, 0); ok {
+			lit.Big = n
+			return lit
+		}
+	}
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
 		p.errors = append(p.errors, msg)
@@ -265,18 +442,59 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return expression
 }
 
+func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+	expression := &ast.PipeExpression{Token: p.curToken, Left: left}
+	p.nextToken()
+	expression.Right = p.parseExpression(PIPE)
+	return expression
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
 	b := ast.BooleanLiteral{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
 	return &b
 }
 
+func (p *Parser) parseNull() ast.Expression {
+	return &ast.NullLiteral{Token: p.curToken}
+}
+
+// parseGroupedExpression parses a parenthesised expression, or the
+// parameter list of an arrow function if => follows the closing paren.
 func (p *Parser) parseGroupedExpression() ast.Expression {
-	p.nextToken()
-	exp := p.parseExpression(LOWEST)
+	start := p.curToken
+	guard := p.guard
+	restore := p.allowArrows()
+	exps := []ast.Expression{}
+	if !p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		exps = append(exps, p.parseExpression(LOWEST))
+		for p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+			p.nextToken()
+			exps = append(exps, p.parseExpression(LOWEST))
+		}
+	}
+	restore()
 	if !p.expectPeek(token.RPAREN) {
 		return nil
 	}
-	return exp
+	if p.peekTokenIs(token.ARROW) && !guard {
+		p.nextToken()
+		return p.parseArrowFunction(start, exps)
+	}
+	if len(exps) != 1 {
+		p.peekError(token.ARROW)
+		return nil
+	}
+	return exps[0]
+}
+
+// allowArrows lifts the ban on arrow functions in a match guard while the
+// brackets nested inside it are parsed. Calling the result restores it.
+func (p *Parser) allowArrows() func() {
+	guard := p.guard
+	p.guard = false
+	return func() { p.guard = guard }
 }
 
 func (p *Parser) parseIfExpression() ast.Expression {
@@ -294,13 +512,37 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	}
 	expression.Consequence = p.parseBlockStatement()
 
-	if p.peekTokenIs(token.ELSE) {
+	if !p.peekTokenIs(token.ELSE) {
+		return expression
+	}
+	p.nextToken()
+	if p.peekTokenIs(token.IF) {
 		p.nextToken()
-		if !p.expectPeek(token.LBRACE) {
+		elseIf, ok := p.parseIfExpression().(*ast.IfExpression)
+		if !ok {
 			return nil
 		}
-		expression.Alternative = p.parseBlockStatement()
+		expression.ElseIf = elseIf
+		return expression
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
 	}
+	expression.Alternative = p.parseBlockStatement()
+	return expression
+}
+
+// parseConditionalExpression parses cond ? a : b. It is right-associative,
+// so a ? b : c ? d : e nests to the right.
+func (p *Parser) parseConditionalExpression(condition ast.Expression) ast.Expression {
+	expression := &ast.ConditionalExpression{Token: p.curToken, Condition: condition}
+	p.nextToken()
+	expression.Consequence = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.COLON) {
+		return nil
+	}
+	p.nextToken()
+	expression.Alternative = p.parseExpression(TERNARY - 1)
 	return expression
 }
 
@@ -320,10 +562,17 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 
 func (p *Parser) parseFunctionExpression() ast.Expression {
 	fn := &ast.FunctionLiteral{Token: p.curToken}
+	if p.peekTokenIs(token.IDENT) {
+		p.nextToken()
+		fn.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
 	if !p.expectPeek(token.LPAREN) {
 		return nil
 	}
-	fn.Parameters = p.parseFunctionParameters()
+	fn.Parameters, fn.Rest = p.parseParameterPatterns()
+	if fn.Parameters == nil {
+		return nil
+	}
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
@@ -331,40 +580,170 @@ func (p *Parser) parseFunctionExpression() ast.Expression {
 	return fn
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	identifiers := []*ast.Identifier{}
-	if p.peekTokenIs(token.RPAREN) {
-		p.nextToken()
-		return identifiers
+// parseArrowFunction parses the body of params => body, with the => as the
+// current token. The parameters were parsed as expressions, so they are
+// turned into patterns here; start is where the arrow function begins.
+func (p *Parser) parseArrowFunction(start token.Token, params []ast.Expression) ast.Expression {
+	start.Type = token.FUNCTION
+	start.Literal = "fn"
+	fn := &ast.FunctionLiteral{Token: start, Parameters: []ast.Expression{}}
+	for i, param := range params {
+		if param == nil {
+			return nil
+		}
+		if spread, ok := param.(*ast.SpreadExpression); ok && i == len(params)-1 {
+			if rest, ok := spread.Value.(*ast.Identifier); ok {
+				fn.Rest = rest
+				continue
+			}
+		}
+		pattern := toParameterPattern(param)
+		if pattern == nil {
+			msg := fmt.Sprintf("invalid arrow function parameter %s", param.String())
+			p.errors = append(p.errors, msg)
+			return nil
+		}
+		fn.Parameters = append(fn.Parameters, pattern)
 	}
 	p.nextToken()
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	identifiers = append(identifiers, ident)
-	for p.peekTokenIs(token.COMMA) {
+	fn.Body = p.parseArrowBody()
+	return fn
+}
+
+// toParameterPattern turns an expression standing for an arrow function
+// parameter into the pattern it spells, or returns nil if it spells none:
+// identifiers, name = default and array literals of those are allowed.
+func toParameterPattern(exp ast.Expression) ast.Expression {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		return exp
+	case *ast.AssignExpression:
+		pattern := toParameterPattern(exp.Target)
+		if pattern == nil {
+			return nil
+		}
+		return &ast.DefaultPattern{Token: exp.Token, Pattern: pattern, Default: exp.Value}
+	case *ast.ArrayLiteral:
+		pattern := &ast.ArrayPattern{Token: exp.Token, Elements: []ast.Expression{}}
+		for i, el := range exp.Elements {
+			if spread, ok := el.(*ast.SpreadExpression); ok && i == len(exp.Elements)-1 {
+				if rest, ok := spread.Value.(*ast.Identifier); ok {
+					pattern.Rest = rest
+					continue
+				}
+			}
+			element := toParameterPattern(el)
+			if element == nil {
+				return nil
+			}
+			pattern.Elements = append(pattern.Elements, element)
+		}
+		return pattern
+	}
+	return nil
+}
+
+// parseFunctionParameters parses a parameter list, which may end with a
+// rest parameter written as ...name.
+func (p *Parser) parseFunctionParameters() ([]*ast.Identifier, *ast.Identifier) {
+	identifiers := []*ast.Identifier{}
+	var rest *ast.Identifier
+	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		if p.peekTokenIs(token.RPAREN) {
+		return identifiers, rest
+	}
+	for {
+		if p.peekTokenIs(token.ELLIPSIS) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return nil, nil
+			}
+			rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 			break
 		}
 		p.nextToken()
 		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 		identifiers = append(identifiers, ident)
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+		if p.peekTokenIs(token.RPAREN) {
+			break
+		}
 	}
 	if !p.expectPeek(token.RPAREN) {
-		return nil
+		return nil, nil
 	}
-	return identifiers
+	return identifiers, rest
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
-	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	exp.Arguments = p.parseCallArguments()
 	return exp
 }
 
+// parseCallArguments parses an argument list, where each argument is an
+// expression, a spread ...expr or a named argument name: expr.
+func (p *Parser) parseCallArguments() []ast.Expression {
+	defer p.allowArrows()()
+	var args []ast.Expression
+	for !p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.COLON) {
+			arg := &ast.NamedArgument{Token: p.curToken}
+			arg.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			p.nextToken()
+			p.nextToken()
+			arg.Value = p.parseExpression(LOWEST)
+			args = append(args, arg)
+		} else {
+			args = append(args, p.parseExpression(LOWEST))
+		}
+		if !p.peekTokenIs(token.RPAREN) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+	p.nextToken()
+	return args
+}
+
+func (p *Parser) parseSpreadExpression() ast.Expression {
+	exp := &ast.SpreadExpression{Token: p.curToken}
+	p.nextToken()
+	exp.Value = p.parseExpression(PREFIX)
+	return exp
+}
+
+// parseIllegal skips a token the lexer could not make sense of. The lexer
+// has already reported it.
+func (p *Parser) parseIllegal() ast.Expression {
+	return nil
+}
+
 func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	str := &ast.InterpolatedString{Token: p.curToken}
+	for {
+		str.Parts = append(str.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+		if p.curTokenIs(token.TEMPLATE_END) {
+			return str
+		}
+		p.nextToken()
+		str.Parts = append(str.Parts, p.parseExpression(LOWEST))
+		if !p.peekTokenIs(token.TEMPLATE_PART) && !p.expectPeek(token.TEMPLATE_END) {
+			return nil
+		}
+		if p.peekTokenIs(token.TEMPLATE_PART) {
+			p.nextToken()
+		}
+	}
+}
+
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.curToken}
 	array.Elements = p.parseExpressionList(token.RBRACKET)
@@ -372,6 +751,7 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 }
 
 func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	defer p.allowArrows()()
 	var list []ast.Expression
 	if p.peekTokenIs(end) {
 		p.nextToken()
@@ -393,13 +773,61 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	return list
 }
 
+// parseIndexExpression parses left[index] and the slices left[start:end]
+// and left[start:end:step], in which every bound is optional.
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+	if !p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		exp.Index = p.parseExpression(LOWEST)
+		if !p.peekTokenIs(token.COLON) {
+			if !p.expectPeek(token.RBRACKET) {
+				return nil
+			}
+			return exp
+		}
+	}
+	slice := &ast.SliceExpression{Token: exp.Token, Left: left, Start: exp.Index}
 	p.nextToken()
-	exp.Index = p.parseExpression(LOWEST)
+	slice.End = p.parseSliceBound()
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		slice.Step = p.parseSliceBound()
+	}
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
+	return slice
+}
+
+func (p *Parser) parseSliceBound() ast.Expression {
+	if p.peekTokenIs(token.COLON) || p.peekTokenIs(token.RBRACKET) {
+		return nil
+	}
+	p.nextToken()
+	return p.parseExpression(LOWEST)
+}
+
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{Token: p.curToken, Object: object}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	exp.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return exp
+}
+
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	switch target.(type) {
+	case *ast.Identifier, *ast.MemberExpression, *ast.IndexExpression:
+	default:
+		msg := fmt.Sprintf("cannot assign to %s", target.String())
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	exp := &ast.AssignExpression{Token: p.curToken, Target: target}
+	p.nextToken()
+	exp.Value = p.parseExpression(ASSIGN - 1)
 	return exp
 }
 
@@ -430,7 +858,7 @@ func (p *Parser) parseMacroLiteral() ast.Expression {
 	if !p.expectPeek(token.LPAREN) {
 		return nil
 	}
-	lit.Parameters = p.parseFunctionParameters()
+	lit.Parameters, lit.Rest = p.parseFunctionParameters()
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"learn-interpreter/ast"
 	"learn-interpreter/lexer"
+	"learn-interpreter/token"
+	"strings"
 	"testing"
 )
 
@@ -178,6 +180,55 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestIntegerLiteralSyntax(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"1_000_000", 1000000},
+		{"0xff", 255},
+		{"0XFF_FF", 65535},
+		{"0o17", 15},
+		{"0b1010_1010", 170},
+		{"9223372036854775807", 9223372036854775807},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		literal := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IntegerLiteral)
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value for %q not %d. got=%d", tt.input, tt.expected, literal.Value)
+		}
+	}
+
+	p := New(lexer.New("0x1_0000_0000_0000_0000"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	literal := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IntegerLiteral)
+	if literal.Big == nil || literal.Big.String() != "18446744073709551616" {
+		t.Errorf("literal.Big wrong. got=%v", literal.Big)
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"1__0", `1:1: invalid number "1__0": _ must separate successive digits`},
+		{"1_", `1:1: invalid number "1_": _ must separate successive digits`},
+		{"0b102", `1:1: invalid number "0b102": invalid digit '2' in binary literal`},
+		{"0x", `1:1: invalid number "0x": no digits after 0x`},
+		{"0o8", `1:1: invalid number "0o8": invalid digit '8' in octal literal`},
+	}
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		if len(p.Errors()) != 1 || p.Errors()[0] != tt.expected {
+			t.Errorf("expected error %q, got=%q", tt.expected, p.Errors())
+		}
+	}
+}
+
 func TestParsingPrefixExpression(t *testing.T) {
 	prefixTests := []struct {
 		input        string
@@ -381,6 +432,18 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a.b.c + d",
+			"(((a.b).c) + d)",
+		},
+		{
+			"-a.b(c).d",
+			"(-((a.b)(c).d))",
+		},
+		{
+			"a[0].b * 2",
+			"(((a[0]).b) * 2)",
+		},
 	}
 	for _, tt := range tests {
 		l := lexer.New(tt.input)
@@ -942,3 +1005,512 @@ func TestMacroLiteralParsing(t *testing.T) {
 	}
 	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
 }
+
+func TestMacroLiteralRestParameter(t *testing.T) {
+	input := `macro(x, ...rest) { x; }`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	macro, ok := stmt.Expression.(*ast.MacroLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.MacroLiteral. got=%T", stmt.Expression)
+	}
+	if len(macro.Parameters) != 1 {
+		t.Fatalf("macro literal parameters wrong. want 1, got=%d\n", len(macro.Parameters))
+	}
+	testLiteralExpression(t, macro.Parameters[0], "x")
+	if macro.Rest == nil {
+		t.Fatalf("macro.Rest is nil")
+	}
+	testLiteralExpression(t, macro.Rest, "rest")
+	if macro.String() != "macro(x, ...rest) x" {
+		t.Errorf("macro.String() wrong. got=%q", macro.String())
+	}
+}
+
+func TestImportAndExportStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "lib/math.es";`, `import "lib/math.es";`},
+		{`import "lib" as l`, `import "lib" as l;`},
+		{`export let x = 1 + 2;`, `export let x = (1 + 2);`},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. got=%q, want=%q", program.String(), tt.expected)
+		}
+	}
+}
+
+func TestStructStatement(t *testing.T) {
+	input := `struct Point { x, y }`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.StructStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.StructStatement. got=%T", program.Statements[0])
+	}
+	if stmt.Name.Value != "Point" {
+		t.Errorf("stmt.Name.Value not %q. got=%q", "Point", stmt.Name.Value)
+	}
+	if len(stmt.Fields) != 2 || stmt.Fields[0].Value != "x" || stmt.Fields[1].Value != "y" {
+		t.Errorf("stmt.Fields wrong. got=%v", stmt.Fields)
+	}
+	if stmt.String() != input {
+		t.Errorf("stmt.String() wrong. got=%q, want=%q", stmt.String(), input)
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`x = 5`, `(x = 5)`},
+		{`p.x = p.x + 1`, `((p.x) = ((p.x) + 1))`},
+		{`a[0] = b = 2`, `((a[0]) = (b = 2))`},
+		{`export struct Point { x }`, `export struct Point { x }`},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. got=%q, want=%q", program.String(), tt.expected)
+		}
+	}
+
+	p := New(lexer.New(`1 = 2`))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 || p.Errors()[0] != "cannot assign to 1" {
+		t.Errorf("expected error %q, got=%v", "cannot assign to 1", p.Errors())
+	}
+}
+
+func TestAssignIndexTargets(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedLeft  string
+		expectedIndex string
+		expectedValue string
+	}{
+		{`a[i] = v`, `a`, `i`, `v`},
+		{`h["k"] = 1 + 2`, `h`, `k`, `(1 + 2)`},
+		{`a[i][j] = v`, `(a[i])`, `j`, `v`},
+		{`f()[0] = v`, `f()`, `0`, `v`},
+		{`p.xs[-1] = v`, `(p.xs)`, `(-1)`, `v`},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("statement is not ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+		assign, ok := stmt.Expression.(*ast.AssignExpression)
+		if !ok {
+			t.Fatalf("expression is not ast.AssignExpression. got=%T", stmt.Expression)
+		}
+		target, ok := assign.Target.(*ast.IndexExpression)
+		if !ok {
+			t.Fatalf("target is not ast.IndexExpression. got=%T", assign.Target)
+		}
+		if target.Left.String() != tt.expectedLeft {
+			t.Errorf("target.Left wrong. got=%q, want=%q", target.Left.String(), tt.expectedLeft)
+		}
+		if target.Index.String() != tt.expectedIndex {
+			t.Errorf("target.Index wrong. got=%q, want=%q", target.Index.String(), tt.expectedIndex)
+		}
+		if assign.Value.String() != tt.expectedValue {
+			t.Errorf("assign.Value wrong. got=%q, want=%q", assign.Value.String(), tt.expectedValue)
+		}
+	}
+
+	for _, input := range []string{`a[1:2] = v`, `f() = v`} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
+
+func TestPipeExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`x |> f`, `(x |> f)`},
+		{`x |> f(a, b)`, `(x |> f(a, b))`},
+		{`xs |> filter(p) |> map(f) |> sum`, `(((xs |> filter(p)) |> map(f)) |> sum)`},
+		{`a + b |> f`, `((a + b) |> f)`},
+		{`a |> f == b`, `(a |> (f == b))`},
+		{`y = x |> f`, `(y = (x |> f))`},
+		{`x |> fn(v) { v }`, `(x |> fn(v) v)`},
+		{`xs |> list.map(f)`, `(xs |> (list.map)(f))`},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. got=%q, want=%q", program.String(), tt.expected)
+		}
+	}
+
+	p := New(lexer.New(`x |> f(a)`))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	pipe, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.PipeExpression)
+	if !ok {
+		t.Fatalf("expression is not *ast.PipeExpression. got=%T", program.Statements[0].(*ast.ExpressionStatement).Expression)
+	}
+	if pipe.Call().String() != "f(x, a)" {
+		t.Errorf("pipe.Call() wrong. got=%q", pipe.Call().String())
+	}
+}
+
+func TestArrowFunctionsAndDeclarations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`x => x * 2`, `fn(x) (x * 2)`},
+		{`(x, y) => x + y`, `fn(x, y) (x + y)`},
+		{`() => 1`, `fn() 1`},
+		{`(x) => { let y = x; y }`, `fn(x) let y = x;y`},
+		{`(x, y = 2, ...rest) => rest`, `fn(x, y = 2, ...rest) rest`},
+		{`([a, b]) => a`, `fn([a, b]) a`},
+		{`xs.map(x => x + 1)`, `(xs.map)(fn(x) (x + 1))`},
+		{`f(x => x, (a, b) => a)`, `f(fn(x) x, fn(a, b) a)`},
+		{`let add = x => y => x + y`, `let add = fn(x) fn(y) (x + y);`},
+		{`(x)`, `x`},
+		{`fn add(a, b) { a + b }`, `fn add(a, b) (a + b)`},
+		{`match (n) { m if m > k => m, x if (ok) => xs.any(y => y) }`,
+			`match (n) { m if (m > k) => m, x if ok => (xs.any)(fn(y) y) }`},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. got=%q, want=%q", program.String(), tt.expected)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{`(1, x) => x`, "invalid arrow function parameter 1"},
+		{`(a, b)`, "expected next token to be =>, got EOF instead"},
+	}
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 || p.Errors()[0] != tt.expected {
+			t.Errorf("expected error %q, got=%q", tt.expected, p.Errors())
+		}
+	}
+}
+
+func TestElseIfAndConditionalExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`if (a) { 1 }`, `if (a) { 1 }`},
+		{`if (a) { 1 } else { 2 }`, `if (a) { 1 } else { 2 }`},
+		{`if (a) { 1 } else if (b) { 2 } else if (c) { 3 } else { 4 }`,
+			`if (a) { 1 } else if (b) { 2 } else if (c) { 3 } else { 4 }`},
+		{`if (x > 1) { let y = x; y * 2 } else {}`, `if ((x > 1)) { let y = x; (y * 2) } else {}`},
+		{`if (a) { 1 } else { if (b) { 2 } }`, `if (a) { 1 } else { if (b) { 2 } }`},
+		{`a ? b : c`, `(a ? b : c)`},
+		{`a ? b : c ? d : e`, `(a ? b : (c ? d : e))`},
+		{`a ? b ? c : d : e`, `(a ? (b ? c : d) : e)`},
+		{`x > 1 ? x + 1 : -x`, `((x > 1) ? (x + 1) : (-x))`},
+		{`y = a == b ? 1 : 2`, `(y = ((a == b) ? 1 : 2))`},
+		{`xs |> f ? 1 : 2`, `((xs |> f) ? 1 : 2)`},
+		{`f(a ? b : c, d)`, `f((a ? b : c), d)`},
+		{`{"k": a ? b : c}`, `{k:(a ? b : c)}`},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. got=%q, want=%q", program.String(), tt.expected)
+		}
+		reparsed := New(lexer.New(program.String()))
+		again := reparsed.ParseProgram()
+		checkParserErrors(t, reparsed)
+		if again.String() != program.String() {
+			t.Errorf("String() does not round-trip. got=%q, want=%q", again.String(), program.String())
+		}
+	}
+
+	p := New(lexer.New(`if (a) { 1 } else if (b) { 2 }`))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	exp := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IfExpression)
+	if exp.Alternative != nil {
+		t.Errorf("exp.Alternative was not nil. got=%q", exp.Alternative.String())
+	}
+	elseIf := exp.ElseIf
+	if elseIf == nil {
+		t.Fatalf("exp.ElseIf is nil")
+	}
+	if elseIf.Token.Type != token.IF || elseIf.Token.Line != 1 || elseIf.Token.Column != 19 {
+		t.Errorf("elseIf.Token wrong. got=%+v", elseIf.Token)
+	}
+	if !testIdentifier(t, elseIf.Condition, "b") {
+		return
+	}
+	if elseIf.Alternative != nil {
+		t.Errorf("elseIf.Alternative was not nil. got=%+v", elseIf.Alternative)
+	}
+
+	p = New(lexer.New(`a ? b`))
+	p.ParseProgram()
+	expected := "expected next token to be :, got EOF instead"
+	if len(p.Errors()) == 0 || p.Errors()[0] != expected {
+		t.Errorf("expected error %q, got=%q", expected, p.Errors())
+	}
+}
+
+func TestConstStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`const limit = 10;`, `const limit = 10;`},
+		{`const [a, b] = pair`, `const [a, b] = pair;`},
+		{`export const version = "1.0"`, `export const version = 1.0;`},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. got=%q, want=%q", program.String(), tt.expected)
+		}
+	}
+
+	p := New(lexer.New(`const x = 1; let y = 2;`))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if !program.Statements[0].(*ast.LetStatement).IsConst() {
+		t.Errorf("const statement is not IsConst")
+	}
+	if program.Statements[1].(*ast.LetStatement).IsConst() {
+		t.Errorf("let statement is IsConst")
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`match (x) { 1 => "one", _ => "other" }`, `match (x) { 1 => one, _ => other }`},
+		{`match (x) { n if n > 0 => n }`, `match (x) { n if (n > 0) => n }`},
+		{`match (xs) { [] => 0, [head, ...tail] => head }`, `match (xs) { [] => 0, [head, ...tail] => head }`},
+		{`match (p) { {name, "age": a} => a }`, `match (p) { {name: name, age: a} => a }`},
+		{`match (x) { -1 => { let y = 2; y } }`, `match (x) { (-1) => let y = 2;y }`},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. got=%q, want=%q", program.String(), tt.expected)
+		}
+	}
+
+	p := New(lexer.New(`match (x) { fn => 1 }`))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 || p.Errors()[0] != "unexpected FUNCTION in pattern" {
+		t.Errorf("expected error %q, got=%v", "unexpected FUNCTION in pattern", p.Errors())
+	}
+}
+
+func TestThrowAndTryExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`throw "boom";`, `throw boom;`},
+		{`try { f() } catch (e) { e.message }`, `try { f() } catch (e) { (e.message) }`},
+		{`try { f() } catch { 0 } finally { g() }`, `try { f() } catch { 0 } finally { g() }`},
+		{`try { f() } finally { g() }`, `try { f() } finally { g() }`},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. got=%q, want=%q", program.String(), tt.expected)
+		}
+	}
+
+	p := New(lexer.New(`try { f() }`))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 || p.Errors()[0] != "try needs a catch or finally block" {
+		t.Errorf("expected error %q, got=%v", "try needs a catch or finally block", p.Errors())
+	}
+}
+
+func TestDestructuringPatterns(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let [a, b, ...rest] = arr;`, `let [a, b, ...rest] = arr;`},
+		{`let {name, age: years} = person;`, `let {name: name, age: years} = person;`},
+		{`let {name = "anon", tags: [first, ...others]} = p;`, `let {name: name = anon, tags: [first, ...others]} = p;`},
+		{`let [x, y = x + 1] = a;`, `let [x, y = (x + 1)] = a;`},
+		{`fn([a, b], {c}, d = 1) { a }`, `fn([a, b], {c: c}, d = 1) a`},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. got=%q, want=%q", program.String(), tt.expected)
+		}
+	}
+}
+
+func TestRestSpreadAndNamedArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`fn(first, ...others) { others }`, `fn(first, ...others) others`},
+		{`fn(x, y = 10) { x }`, `fn(x, y = 10) x`},
+		{`f(...args)`, `f(...args)`},
+		{`f(1, ...[2, 3], 4)`, `f(1, ...[2, 3], 4)`},
+		{`f(1, y: 2 + 3)`, `f(1, y: (2 + 3))`},
+		{`[0, ...rest]`, `[0, ...rest]`},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. got=%q, want=%q", program.String(), tt.expected)
+		}
+	}
+}
+
+func TestSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`a[1:2]`, `(a[1:2])`},
+		{`a[:2]`, `(a[:2])`},
+		{`a[1:]`, `(a[1:])`},
+		{`a[:]`, `(a[:])`},
+		{`a[::-1]`, `(a[::(-1)])`},
+		{`a[1 + 1:n:2]`, `(a[(1 + 1):n:2])`},
+		{`a[-1]`, `(a[(-1)])`},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if program.String() != tt.expected {
+			t.Errorf("program.String() wrong. got=%q, want=%q", program.String(), tt.expected)
+		}
+	}
+}
+
+func TestInterpolatedStringParsing(t *testing.T) {
+	input := `"Hello ${name}, you have ${len(items) + 1} items"`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	str, ok := stmt.Expression.(*ast.InterpolatedString)
+	if !ok {
+		t.Fatalf("exp not *ast.InterpolatedString. got=%T", stmt.Expression)
+	}
+	if len(str.Parts) != 5 {
+		t.Fatalf("wrong number of parts. want 5, got=%d", len(str.Parts))
+	}
+	testIdentifier(t, str.Parts[1], "name")
+	expected := "Hello ${name}, you have ${(len(items) + 1)} items"
+	if str.String() != expected {
+		t.Errorf("str.String() wrong. got=%q, want=%q", str.String(), expected)
+	}
+}
+
+func TestLexerErrorsAreReported(t *testing.T) {
+	p := New(lexer.New(`let s = "bad \q"; let t = "ok";`))
+	program := p.ParseProgram()
+	expected := []string{`1:14: invalid escape \q`}
+	if len(p.Errors()) != 1 || p.Errors()[0] != expected[0] {
+		t.Fatalf("wrong errors. expected=%q, got=%q", expected, p.Errors())
+	}
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+}
+
+func TestLexerErrorsAreNotReportedTwice(t *testing.T) {
+	p := New(lexer.New("let a = 1 @ 2;\nlet b = f(1 # 2);\nlet c = 12abc;"))
+	p.ParseProgram()
+	expected := []string{
+		"1:11: unexpected character '@'",
+		"2:13: unexpected character '#'",
+		`3:9: invalid number "12abc": invalid digit 'a' in decimal literal`,
+	}
+	errors := p.Errors()
+	if len(errors) < len(expected) {
+		t.Fatalf("wrong errors. expected=%q, got=%q", expected, errors)
+	}
+	for i, msg := range expected {
+		if errors[i] != msg {
+			t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, msg, errors[i])
+		}
+	}
+	for _, msg := range errors[len(expected):] {
+		if strings.Contains(msg, token.ILLEGAL) {
+			t.Errorf("parser reported an ILLEGAL token: %q", msg)
+		}
+	}
+}
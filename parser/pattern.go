@@ -0,0 +1,228 @@
+package parser
+
+import (
+	"fmt"
+	"learn-interpreter/ast"
+	"learn-interpreter/token"
+)
+
+func (p *Parser) parseMatchExpression() ast.Expression {
+	exp := &ast.MatchExpression{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	exp.Subject = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	exp.Arms = []*ast.MatchArm{}
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		arm := p.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+		exp.Arms = append(exp.Arms, arm)
+		if p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+		}
+	}
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+	return exp
+}
+
+// parseMatchArm parses `pattern [if guard] => body`, where body is either a
+// block or a single expression.
+func (p *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{Pattern: p.parsePattern()}
+	if arm.Pattern == nil {
+		return nil
+	}
+	if p.peekTokenIs(token.IF) {
+		p.nextToken()
+		p.nextToken()
+		guard := p.guard
+		p.guard = true
+		arm.Guard = p.parseExpression(LOWEST)
+		p.guard = guard
+	}
+	if !p.expectPeek(token.ARROW) {
+		return nil
+	}
+	p.nextToken()
+	arm.Body = p.parseArrowBody()
+	return arm
+}
+
+// parseArrowBody parses what follows the => of a match arm or an arrow
+// function: a block, or a single expression that becomes the only
+// statement of one.
+func (p *Parser) parseArrowBody() *ast.BlockStatement {
+	if p.curTokenIs(token.LBRACE) {
+		return p.parseBlockStatement()
+	}
+	stmt := &ast.ExpressionStatement{Token: p.curToken, Expression: p.parseExpression(LOWEST)}
+	return &ast.BlockStatement{Token: p.curToken, Statements: []ast.Statement{stmt}}
+}
+
+// parseParameterPatterns parses the parameter list of a function literal.
+// Each parameter is a pattern with an optional default value, and the list
+// may end with a rest parameter written as ...name.
+func (p *Parser) parseParameterPatterns() ([]ast.Expression, *ast.Identifier) {
+	params := []ast.Expression{}
+	var rest *ast.Identifier
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return params, rest
+	}
+	for {
+		p.nextToken()
+		if p.curTokenIs(token.ELLIPSIS) {
+			if !p.expectPeek(token.IDENT) {
+				return nil, nil
+			}
+			rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			break
+		}
+		param := p.parsePatternElement()
+		if param == nil {
+			return nil, nil
+		}
+		params = append(params, param)
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+		if p.peekTokenIs(token.RPAREN) {
+			break
+		}
+	}
+	if !p.expectPeek(token.RPAREN) {
+		return nil, nil
+	}
+	return params, rest
+}
+
+// parsePatternElement parses a pattern that may be followed by = and a
+// default value, as allowed in parameter lists and inside array and hash
+// patterns.
+func (p *Parser) parsePatternElement() ast.Expression {
+	pattern := p.parsePattern()
+	if pattern == nil {
+		return nil
+	}
+	return p.parseDefault(pattern)
+}
+
+func (p *Parser) parseDefault(pattern ast.Expression) ast.Expression {
+	if !p.peekTokenIs(token.ASSIGN) {
+		return pattern
+	}
+	p.nextToken()
+	exp := &ast.DefaultPattern{Token: p.curToken, Pattern: pattern}
+	p.nextToken()
+	if exp.Default = p.parseExpression(ASSIGN); exp.Default == nil {
+		return nil
+	}
+	return exp
+}
+
+// parsePattern parses an identifier (_ matches anything without binding),
+// a literal, an array pattern or a hash pattern.
+func (p *Parser) parsePattern() ast.Expression {
+	switch p.curToken.Type {
+	case token.IDENT:
+		return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	case token.LBRACKET:
+		return p.parseArrayPattern()
+	case token.LBRACE:
+		return p.parseHashPattern()
+	default:
+		return p.parseLiteralPattern()
+	}
+}
+
+func (p *Parser) parseLiteralPattern() ast.Expression {
+	switch p.curToken.Type {
+	case token.INT, token.STRING, token.TRUE, token.FALSE, token.NULL, token.MINUS:
+		return p.parseExpression(PREFIX)
+	default:
+		msg := fmt.Sprintf("unexpected %s in pattern", p.curToken.Type)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+}
+
+func (p *Parser) parseArrayPattern() ast.Expression {
+	pattern := &ast.ArrayPattern{Token: p.curToken, Elements: []ast.Expression{}}
+	if p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		return pattern
+	}
+	for {
+		p.nextToken()
+		if p.curTokenIs(token.ELLIPSIS) {
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			pattern.Rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			break
+		}
+		element := p.parsePatternElement()
+		if element == nil {
+			return nil
+		}
+		pattern.Elements = append(pattern.Elements, element)
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+		if p.peekTokenIs(token.RBRACKET) {
+			break
+		}
+	}
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+	return pattern
+}
+
+func (p *Parser) parseHashPattern() ast.Expression {
+	pattern := &ast.HashPattern{Token: p.curToken, Pairs: []*ast.HashPatternPair{}}
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		pair := &ast.HashPatternPair{}
+		if p.curTokenIs(token.IDENT) {
+			pair.Key = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+			pair.Value = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		} else if pair.Key = p.parseLiteralPattern(); pair.Key == nil {
+			return nil
+		} else if !p.peekTokenIs(token.COLON) {
+			p.peekError(token.COLON)
+			return nil
+		}
+		if p.peekTokenIs(token.COLON) {
+			p.nextToken()
+			p.nextToken()
+			if pair.Value = p.parsePatternElement(); pair.Value == nil {
+				return nil
+			}
+		} else if pair.Value = p.parseDefault(pair.Value); pair.Value == nil {
+			return nil
+		}
+		pattern.Pairs = append(pattern.Pairs, pair)
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+	return pattern
+}
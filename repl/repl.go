@@ -4,18 +4,33 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"learn-interpreter/ast"
 	"learn-interpreter/eval"
 	"learn-interpreter/lexer"
 	"learn-interpreter/object"
 	"learn-interpreter/parser"
+	"strings"
 )
 
 const Prompt = ">>"
 
-func Start(in io.Reader, out io.Writer) {
+const (
+	// ExpandCommand prints the rest of the line after macro expansion
+	// instead of evaluating it.
+	ExpandCommand = ":expand"
+	// TraceCommand toggles logging of every macro expansion step.
+	TraceCommand = ":trace"
+)
+
+// Start runs the interactive loop, loading the modules imported by each line
+// with modules.
+func Start(in io.Reader, out io.Writer, modules *eval.ModuleLoader) {
 	scanner := bufio.NewScanner(in)
 	env := object.NewEnvironment()
 	macroEnv := object.NewEnvironment()
+	env.SetImporter("", modules)
+	env.SetMacros(macroEnv)
+	tracing := false
 
 	for {
 		fmt.Fprintf(out, Prompt)
@@ -25,16 +40,34 @@ func Start(in io.Reader, out io.Writer) {
 		}
 
 		line := scanner.Text()
-		l := lexer.New(line)
-		p := parser.New(l)
-		program := p.ParseProgram()
-		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
+		if strings.TrimSpace(line) == TraceCommand {
+			tracing = !tracing
+			if tracing {
+				io.WriteString(out, "macro trace on\n")
+			} else {
+				io.WriteString(out, "macro trace off\n")
+			}
 			continue
 		}
+		expandOnly := strings.HasPrefix(line, ExpandCommand+" ")
+		if expandOnly {
+			line = strings.TrimPrefix(line, ExpandCommand+" ")
+		}
 
-		eval.DefineMacros(program, macroEnv)
-		expanded := eval.ExpandMacros(program, macroEnv)
+		expander := eval.NewMacroExpander()
+		expander.Modules = modules
+		if tracing {
+			expander.Trace = out
+		}
+		expanded, ok := parseAndExpand(line, out, expander, macroEnv)
+		if !ok {
+			continue
+		}
+		if expandOnly {
+			io.WriteString(out, expanded.String())
+			io.WriteString(out, "\n")
+			continue
+		}
 		evaluated := eval.Eval(expanded, env)
 		if evaluated != nil {
 			io.WriteString(out, evaluated.Inspect())
@@ -43,9 +76,54 @@ func Start(in io.Reader, out io.Writer) {
 	}
 }
 
+// Run evaluates a whole program, reporting parser and macro errors the same
+// way the interactive loop does. It returns false if the program failed.
+func Run(input string, out io.Writer, expander *eval.MacroExpander) bool {
+	macroEnv := object.NewEnvironment()
+	expanded, ok := parseAndExpand(input, out, expander, macroEnv)
+	if !ok {
+		return false
+	}
+	env := object.NewEnvironment()
+	env.SetImporter(expander.File, expander.Modules)
+	env.SetMacros(macroEnv)
+	evaluated := eval.Eval(expanded, env)
+	if errObj, ok := evaluated.(*object.Error); ok {
+		io.WriteString(out, errObj.Inspect())
+		io.WriteString(out, "\n")
+		return false
+	}
+	return true
+}
+
+func parseAndExpand(input string, out io.Writer, expander *eval.MacroExpander,
+	macroEnv *object.Environment) (ast.Node, bool) {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParserErrors(out, p.Errors())
+		return nil, false
+	}
+
+	expanded := expander.Expand(program, macroEnv)
+	if len(expander.Errors()) != 0 {
+		printMacroErrors(out, expander.Errors())
+		return nil, false
+	}
+	return expanded, true
+}
+
 func printParserErrors(out io.Writer, errors []string) {
 	for _, msg := range errors {
 		io.WriteString(out, " parser errors:\n")
 		io.WriteString(out, "\t"+msg+"\n")
 	}
 }
+
+func printMacroErrors(out io.Writer, errors []*eval.MacroError) {
+	for _, err := range errors {
+		io.WriteString(out, " macro errors:\n")
+		io.WriteString(out, "\t"+err.Error()+"\n")
+	}
+}
@@ -1,12 +1,29 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
+// Token is a lexeme and where it starts in the source. Line and Column
+// count from 1, with Column counted in characters; Offset is the byte
+// offset from the start of the input. File is empty unless the lexer was
+// given a file name.
 type Token struct {
 	Type    TokenType
 	Literal string
 	Line    int
-	Row     int
+	Column  int
+	Offset  int
+	File    string
+}
+
+// Position formats where the token starts as file:line:column, leaving
+// out the file when it is unknown.
+func (t Token) Position() string {
+	if t.File == "" {
+		return fmt.Sprintf("%d:%d", t.Line, t.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", t.File, t.Line, t.Column)
 }
 
 const (
@@ -36,6 +53,11 @@ const (
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
+	QUESTION  = "?"
+	ELLIPSIS  = "..."
+	DOT       = "."
+	ARROW     = "=>"
+	PIPE      = "|>"
 
 	LPAREN   = "("
 	RPAREN   = ")"
@@ -47,25 +69,50 @@ const (
 	// 关键字
 	FUNCTION = "FUNCTION"
 	LET      = "LET"
+	CONST    = "CONST"
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
 	MACRO    = "MACRO"
+	NULL     = "NULL"
+	IMPORT   = "IMPORT"
+	EXPORT   = "EXPORT"
+	STRUCT   = "STRUCT"
+	MATCH    = "MATCH"
+	THROW    = "THROW"
+	TRY      = "TRY"
+	CATCH    = "CATCH"
+	FINALLY  = "FINALLY"
 
 	STRING = "STRING"
+	// An interpolated string "a ${x} b ${y} c" is lexed as TEMPLATE_PART
+	// "a ", the tokens of x, TEMPLATE_PART " b ", the tokens of y and
+	// TEMPLATE_END " c".
+	TEMPLATE_PART = "TEMPLATE_PART"
+	TEMPLATE_END  = "TEMPLATE_END"
 )
 
 var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
-	"macro":  MACRO,
+	"fn":      FUNCTION,
+	"let":     LET,
+	"const":   CONST,
+	"true":    TRUE,
+	"false":   FALSE,
+	"if":      IF,
+	"else":    ELSE,
+	"return":  RETURN,
+	"macro":   MACRO,
+	"null":    NULL,
+	"import":  IMPORT,
+	"export":  EXPORT,
+	"struct":  STRUCT,
+	"match":   MATCH,
+	"throw":   THROW,
+	"try":     TRY,
+	"catch":   CATCH,
+	"finally": FINALLY,
 }
 
 func LookupIdent(ident string) TokenType {